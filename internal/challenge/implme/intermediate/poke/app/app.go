@@ -1,10 +1,12 @@
 package app
 
 import (
+	"context"
 	"log"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -27,16 +29,24 @@ type PokeAPP interface {
 }
 
 type pokeAPP struct {
-	pokeClient client.PokeClient
+	pokeClient client.ContextPokeClient
+
+	ctx          context.Context    // ctx lives as long as the app, it is the parent of every lookup.
+	stop         context.CancelFunc // stop cancels ctx once the window is closed.
+	mu           sync.Mutex
+	cancelLookup context.CancelFunc // cancelLookup cancels the lookup in progress, a new input supersedes it.
 
 	header *widget.Label
 	img    *canvas.Image
 	input  *widget.Entry
 }
 
-func NewPokeApp(pokeClient client.PokeClient) PokeAPP {
+func NewPokeApp(pokeClient client.ContextPokeClient) PokeAPP {
+	ctx, stop := context.WithCancel(context.Background())
 	return &pokeAPP{
 		pokeClient: pokeClient,
+		ctx:        ctx,
+		stop:       stop,
 		header:     createHeader(),
 		img:        imageFromURL(defaultURL),
 		input:      widget.NewEntry(),
@@ -54,11 +64,15 @@ func (p *pokeAPP) Start() {
 
 	content := container.NewStack(container.NewVBox(p.header, p.input), p.img)
 	myWindow.SetContent(content)
+	myWindow.SetOnClosed(p.stop) // Cancel the lookups in progress.
 	myWindow.ShowAndRun()
 }
 
 func (p *pokeAPP) OnChanged(ID string) {
-	_, err := p.fetchAndUpdatePokemon(ID)
+	ctx, cancel := p.newLookup()
+	defer cancel()
+
+	_, err := p.fetchAndUpdatePokemon(ctx, ID)
 	if err != nil {
 		slog.Error("fetchAndUpdatePokemon", "error", err)
 	}
@@ -76,13 +90,33 @@ func (p *pokeAPP) OnChangedNonBlocking(ID string) {
 	panic("implement me!")
 }
 
-func (p *pokeAPP) fetchAndUpdatePokemon(ID string) (bool, error) {
+// newLookup returns the context of a new lookup, and cancels the previous one since its result is no longer wanted.
+func (p *pokeAPP) newLookup() (context.Context, context.CancelFunc) {
+	parent := p.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancelLookup != nil {
+		p.cancelLookup()
+	}
+	p.cancelLookup = cancel
+	return ctx, cancel
+}
+
+func (p *pokeAPP) fetchAndUpdatePokemon(ctx context.Context, ID string) (bool, error) {
 	if ID == "" {
 		p.setImage(defaultURL)
 		return true, nil
 	}
 
-	poke, err := p.pokeClient.FetchPokemon(ID)
+	poke, err := p.pokeClient.FetchPokemonContext(ctx, ID)
+	if ctx.Err() != nil {
+		return false, nil // Superseded by a newer input, or the app is closing.
+	}
 	if err != nil {
 		p.setName("Not Found")
 		p.setImage(notFoundImageURL)
@@ -11,7 +11,7 @@ import (
 
 func Test_pokeAPP_OnChangedNonBlocking(t *testing.T) {
 	type fields struct {
-		pokeClient func(t *testing.T) client.PokeClient
+		pokeClient func(t *testing.T) client.ContextPokeClient
 	}
 	type args struct {
 		ID string
@@ -23,9 +23,9 @@ func Test_pokeAPP_OnChangedNonBlocking(t *testing.T) {
 	}{
 		{
 			name: "test non blocking",
-			fields: fields{func(t *testing.T) client.PokeClient {
-				pokeClient := mocks.NewPokeClient(t)
-				pokeClient.EXPECT().FetchPokemon(mock.Anything).Maybe().Run(func(args mock.Arguments) {
+			fields: fields{func(t *testing.T) client.ContextPokeClient {
+				pokeClient := mocks.NewContextPokeClient(t)
+				pokeClient.EXPECT().FetchPokemonContext(mock.Anything, mock.Anything).Maybe().Run(func(args mock.Arguments) {
 					// If the call is blocking the test will not finish successfully
 					var nilChan chan struct{}
 					nilChan <- struct{}{}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mtslzr/pokeapi-go/structs"
+)
+
+// CacheConfig configures a CachingClient.
+type CacheConfig struct {
+	// TTL is how long a fetched Pokémon is kept.
+	TTL time.Duration
+	// NotFoundTTL is how long an ErrNotFound is kept, zero disables negative caching.
+	NotFoundTTL time.Duration
+	// MaxEntries caps the number of cached IDs, the least recently used ones are evicted first.
+	MaxEntries int
+}
+
+// CacheStats counts how FetchPokemon calls were served.
+type CacheStats struct {
+	// Hits counts the calls served from the cache, including cached ErrNotFound.
+	Hits int64
+	// Misses counts the calls that went to the upstream client.
+	Misses int64
+	// Coalesced counts the calls that shared the upstream call of a concurrent call for the same ID.
+	Coalesced int64
+}
+
+// CachingClient is a PokeClient and a ContextPokeClient that caches the Pokémon fetched by another client, and
+// collapses concurrent fetches of the same ID into a single upstream call, singleflight style. IDs are trimmed and
+// lowercased, like the names PokeAPI knows, before they are looked up and passed to the upstream client.
+//
+// Every caller waits for the shared call with its own context; the call is cancelled once all of them gave up.
+type CachingClient struct {
+	next   ContextPokeClient
+	config CacheConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element // entries indexes the elements of lru by ID.
+	lru      *list.List               // lru holds *cacheEntry values, most recently used first.
+	inFlight map[string]*call         // inFlight holds the upstream calls in progress by ID.
+
+	hits, misses, coalesced atomic.Int64
+}
+
+// call is an upstream call shared by all the callers fetching the same ID.
+type call struct {
+	done    chan struct{} // done is closed once pokemon and err are set.
+	pokemon *structs.Pokemon
+	err     error
+
+	cancel  context.CancelFunc // cancel cancels the upstream call.
+	waiters int                // waiters counts the callers waiting for the call, guarded by CachingClient.mu.
+}
+
+type cacheEntry struct {
+	id        string
+	pokemon   *structs.Pokemon
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachingClient creates a CachingClient in front of next, use WithContext to put it in front of a PokeClient.
+func NewCachingClient(next ContextPokeClient, config CacheConfig) *CachingClient {
+	return &CachingClient{
+		next:     next,
+		config:   config,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		inFlight: make(map[string]*call),
+	}
+}
+
+// FetchPokemon returns the cached Pokémon for ID, or fetches it from the upstream client.
+func (c *CachingClient) FetchPokemon(ID string) (*structs.Pokemon, error) {
+	return c.FetchPokemonContext(context.Background(), ID)
+}
+
+// FetchPokemonContext returns the cached Pokémon for ID, or fetches it from the upstream client, giving up once the
+// context is done.
+func (c *CachingClient) FetchPokemonContext(ctx context.Context, ID string) (*structs.Pokemon, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key := strings.ToLower(strings.TrimSpace(ID))
+
+	c.mu.Lock()
+	if entry, ok := c.get(key); ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return copyPokemon(entry.pokemon, entry.err)
+	}
+	shared, ok := c.inFlight[key]
+	if ok {
+		c.coalesced.Add(1)
+	} else {
+		c.misses.Add(1)
+		shared = c.start(ctx, key)
+	}
+	shared.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-shared.done:
+		return copyPokemon(shared.pokemon, shared.err)
+	case <-ctx.Done():
+		c.mu.Lock()
+		shared.waiters--
+		if shared.waiters == 0 {
+			// Nobody is waiting for the result anymore, the next caller starts a new call rather than sharing
+			// the cancelled one.
+			shared.cancel()
+			delete(c.inFlight, key)
+		}
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// start starts the upstream call for key, c.mu must be held. The call keeps the values of ctx but not its
+// cancellation, which belongs to the first caller only.
+func (c *CachingClient) start(ctx context.Context, key string) *call {
+	callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	shared := &call{done: make(chan struct{}), cancel: cancel}
+	c.inFlight[key] = shared
+
+	go func() {
+		defer cancel()
+		pokemon, err := c.next.FetchPokemonContext(callCtx, key)
+
+		c.mu.Lock()
+		if c.inFlight[key] == shared {
+			delete(c.inFlight, key)
+		}
+		c.set(key, pokemon, err)
+		shared.pokemon, shared.err = pokemon, err
+		c.mu.Unlock()
+		close(shared.done)
+	}()
+	return shared
+}
+
+// Stats returns the cache metrics.
+func (c *CachingClient) Stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Coalesced: c.coalesced.Load(),
+	}
+}
+
+// Len returns the number of cached IDs, including expired ones not evicted yet.
+func (c *CachingClient) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// get returns the entry of key if it is cached and not expired, c.mu must be held.
+func (c *CachingClient) get(key string) (*cacheEntry, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry, true
+}
+
+// set caches the outcome of an upstream call, errors other than ErrNotFound are not cached. c.mu must be held.
+func (c *CachingClient) set(key string, pokemon *structs.Pokemon, err error) {
+	ttl := c.config.TTL
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return
+		}
+		ttl = c.config.NotFoundTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	entry := &cacheEntry{id: key, pokemon: pokemon, err: err, expiresAt: c.now().Add(ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.config.MaxEntries > 0 && c.lru.Len() > c.config.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove evicts an element, c.mu must be held.
+func (c *CachingClient) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).id)
+}
+
+// copyPokemon returns a deep copy of pokemon along with err, so that callers cannot modify the cached value.
+// A Pokémon is made of nested slices and interface values decoded from JSON, so it is copied through JSON.
+func copyPokemon(pokemon *structs.Pokemon, err error) (*structs.Pokemon, error) {
+	if pokemon == nil {
+		return nil, err
+	}
+	data, marshalErr := json.Marshal(pokemon)
+	if marshalErr != nil {
+		return nil, fmt.Errorf("copying pokemon: %w", marshalErr)
+	}
+	var copied structs.Pokemon
+	if unmarshalErr := json.Unmarshal(data, &copied); unmarshalErr != nil {
+		return nil, fmt.Errorf("copying pokemon: %w", unmarshalErr)
+	}
+	return &copied, err
+}
@@ -0,0 +1,312 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mtslzr/pokeapi-go/structs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/romangurevitch/gophercon2023/internal/challenge/implme/intermediate/poke/client/mocks"
+)
+
+var errUpstream = errors.New("upstream error")
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestCachingClient(next ContextPokeClient, config CacheConfig) (*CachingClient, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	c := NewCachingClient(next, config)
+	c.now = clock.Now
+	return c, clock
+}
+
+func TestCachingClient_FetchPokemon(t *testing.T) {
+	pikachu := &structs.Pokemon{ID: 25, Name: "pikachu"}
+	notFound := errors.Join(ErrNotFound, errors.New("9999"))
+
+	tests := []struct {
+		name      string
+		setup     func(m *mocks.PokeClient)
+		calls     []string
+		advance   time.Duration // advance moves the clock before the last call
+		want      *structs.Pokemon
+		wantErr   error
+		wantStats CacheStats
+	}{
+		{
+			name: "Miss then hit",
+			setup: func(m *mocks.PokeClient) {
+				m.EXPECT().FetchPokemon("25").Return(pikachu, nil).Once()
+			},
+			calls:     []string{"25", " 25 ", "25"},
+			want:      pikachu,
+			wantStats: CacheStats{Hits: 2, Misses: 1},
+		},
+		{
+			name: "Names are case insensitive",
+			setup: func(m *mocks.PokeClient) {
+				m.EXPECT().FetchPokemon("pikachu").Return(pikachu, nil).Once()
+			},
+			calls:     []string{"Pikachu", "pikachu", " PIKACHU "},
+			want:      pikachu,
+			wantStats: CacheStats{Hits: 2, Misses: 1},
+		},
+		{
+			name: "Expired entries are fetched again",
+			setup: func(m *mocks.PokeClient) {
+				m.EXPECT().FetchPokemon("25").Return(pikachu, nil).Twice()
+			},
+			calls:     []string{"25", "25"},
+			advance:   time.Minute,
+			want:      pikachu,
+			wantStats: CacheStats{Misses: 2},
+		},
+		{
+			name: "Not found is cached",
+			setup: func(m *mocks.PokeClient) {
+				m.EXPECT().FetchPokemon("9999").Return(nil, notFound).Once()
+			},
+			calls:     []string{"9999", "9999"},
+			wantErr:   ErrNotFound,
+			wantStats: CacheStats{Hits: 1, Misses: 1},
+		},
+		{
+			name: "Not found expires sooner",
+			setup: func(m *mocks.PokeClient) {
+				m.EXPECT().FetchPokemon("9999").Return(nil, notFound).Twice()
+			},
+			calls:     []string{"9999", "9999"},
+			advance:   10 * time.Second,
+			wantErr:   ErrNotFound,
+			wantStats: CacheStats{Misses: 2},
+		},
+		{
+			name: "Other errors are not cached",
+			setup: func(m *mocks.PokeClient) {
+				m.EXPECT().FetchPokemon("25").Return(nil, errUpstream).Once()
+				m.EXPECT().FetchPokemon("25").Return(pikachu, nil).Once()
+			},
+			calls:     []string{"25", "25"},
+			want:      pikachu,
+			wantStats: CacheStats{Misses: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mocks.NewPokeClient(t)
+			tt.setup(m)
+			c, clock := newTestCachingClient(WithContext(m), CacheConfig{TTL: time.Minute, NotFoundTTL: 10 * time.Second})
+
+			var got *structs.Pokemon
+			var err error
+			for i, ID := range tt.calls {
+				if i == len(tt.calls)-1 {
+					clock.Advance(tt.advance)
+				}
+				got, err = c.FetchPokemon(ID)
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantStats, c.Stats())
+		})
+	}
+}
+
+func TestCachingClient_CoalescesConcurrentFetches(t *testing.T) {
+	const callers = 10
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	m := mocks.NewPokeClient(t)
+	m.EXPECT().FetchPokemon("pikachu").RunAndReturn(func(string) (*structs.Pokemon, error) {
+		close(started)
+		<-release
+		return &structs.Pokemon{Name: "pikachu"}, nil
+	}).Once()
+	c, _ := newTestCachingClient(WithContext(m), CacheConfig{TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	fetch := func() {
+		defer wg.Done()
+		got, err := c.FetchPokemon("pikachu")
+		assert.NoError(t, err)
+		assert.Equal(t, "pikachu", got.Name)
+	}
+	wg.Add(1)
+	go fetch()
+	<-started // the first caller is fetching, the others join its call
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go fetch()
+	}
+	assert.Eventually(t, func() bool {
+		return c.Stats().Coalesced == callers-1
+	}, time.Second, time.Millisecond, "waiting for the callers to join the upstream call")
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, CacheStats{Misses: 1, Coalesced: callers - 1}, c.Stats())
+	got, err := c.FetchPokemon("pikachu")
+	assert.NoError(t, err)
+	assert.Equal(t, "pikachu", got.Name)
+	assert.Equal(t, int64(1), c.Stats().Hits)
+}
+
+func TestCachingClient_EvictsLeastRecentlyUsed(t *testing.T) {
+	m := mocks.NewPokeClient(t)
+	for _, ID := range []string{"1", "2", "3"} {
+		m.EXPECT().FetchPokemon(ID).Return(&structs.Pokemon{Name: ID}, nil).Once()
+	}
+	m.EXPECT().FetchPokemon("2").Return(&structs.Pokemon{Name: "2"}, nil).Once() // refetched after eviction
+	c, _ := newTestCachingClient(WithContext(m), CacheConfig{TTL: time.Minute, MaxEntries: 2})
+
+	for _, ID := range []string{"1", "2", "1", "3", "1", "2"} {
+		got, err := c.FetchPokemon(ID)
+		assert.NoError(t, err)
+		assert.Equal(t, ID, got.Name)
+	}
+
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 4}, c.Stats())
+}
+
+func TestCachingClient_ReturnsCopies(t *testing.T) {
+	var pikachu structs.Pokemon
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"pikachu","types":[{"slot":1,"type":{"name":"electric"}}]}`), &pikachu))
+	m := mocks.NewPokeClient(t)
+	m.EXPECT().FetchPokemon("25").Return(&pikachu, nil).Once()
+	c, _ := newTestCachingClient(WithContext(m), CacheConfig{TTL: time.Minute})
+
+	got, err := c.FetchPokemon("25")
+	assert.NoError(t, err)
+	got.Name = "raichu"
+	got.Types[0].Type.Name = "water"
+
+	got, err = c.FetchPokemon("25")
+	assert.NoError(t, err)
+	assert.Equal(t, "pikachu", got.Name)
+	assert.Equal(t, "electric", got.Types[0].Type.Name, "nested values should not be shared")
+}
+
+func TestCachingClient_CancelsUpstreamOnceAllWaitersGiveUp(t *testing.T) {
+	upstreamDone := make(chan error, 1)
+	m := mocks.NewContextPokeClient(t)
+	m.EXPECT().FetchPokemonContext(mock.Anything, "25").RunAndReturn(func(ctx context.Context, _ string) (*structs.Pokemon, error) {
+		<-ctx.Done()
+		upstreamDone <- ctx.Err()
+		return nil, ctx.Err()
+	}).Once()
+	m.EXPECT().FetchPokemonContext(mock.Anything, "25").Return(&structs.Pokemon{Name: "pikachu"}, nil).Once()
+	c, _ := newTestCachingClient(m, CacheConfig{TTL: time.Minute})
+
+	impatient, cancelImpatient := context.WithCancel(context.Background())
+	patient, cancelPatient := context.WithCancel(context.Background())
+	results := make(chan error, 2)
+	for _, ctx := range []context.Context{impatient, patient} {
+		go func(ctx context.Context) {
+			_, err := c.FetchPokemonContext(ctx, "25")
+			results <- err
+		}(ctx)
+	}
+	assert.Eventually(t, func() bool {
+		stats := c.Stats()
+		return stats.Misses == 1 && stats.Coalesced == 1
+	}, time.Second, time.Millisecond)
+
+	cancelImpatient()
+	assert.ErrorIs(t, <-results, context.Canceled)
+	select {
+	case <-upstreamDone:
+		t.Fatal("upstream call should keep running while a caller is waiting")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancelPatient()
+	assert.ErrorIs(t, <-results, context.Canceled)
+	assert.ErrorIs(t, <-upstreamDone, context.Canceled, "upstream call should be cancelled once all callers gave up")
+
+	// the cancellation is not cached, the next caller fetches again
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.inFlight) == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, c.Len())
+	got, err := c.FetchPokemonContext(context.Background(), "25")
+	assert.NoError(t, err)
+	assert.Equal(t, "pikachu", got.Name)
+}
+
+func TestCachingClient_NewCallerAfterAllWaitersGaveUp(t *testing.T) {
+	release := make(chan struct{})
+	m := mocks.NewContextPokeClient(t)
+	m.EXPECT().FetchPokemonContext(mock.Anything, "25").RunAndReturn(func(ctx context.Context, _ string) (*structs.Pokemon, error) {
+		<-release // slow to notice the cancellation
+		return nil, ctx.Err()
+	}).Once()
+	m.EXPECT().FetchPokemonContext(mock.Anything, "25").Return(&structs.Pokemon{Name: "pikachu"}, nil).Once()
+	c, _ := newTestCachingClient(m, CacheConfig{TTL: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan error, 1)
+	go func() {
+		_, err := c.FetchPokemonContext(ctx, "25")
+		results <- err
+	}()
+	assert.Eventually(t, func() bool { return c.Stats().Misses == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-results, context.Canceled)
+
+	// the cancelled call has not returned yet, the next caller must not share it
+	got, err := c.FetchPokemonContext(context.Background(), "25")
+	assert.NoError(t, err)
+	assert.Equal(t, "pikachu", got.Name)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.inFlight) == 0
+	}, time.Second, time.Millisecond)
+	got, err = c.FetchPokemonContext(context.Background(), "25")
+	assert.NoError(t, err)
+	assert.Equal(t, "pikachu", got.Name, "the cancelled call should not overwrite the cached Pokémon")
+}
+
+func TestCachingClient_Deadline(t *testing.T) {
+	m := mocks.NewContextPokeClient(t)
+	m.EXPECT().FetchPokemonContext(mock.Anything, "25").RunAndReturn(func(ctx context.Context, _ string) (*structs.Pokemon, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}).Once()
+	c, _ := newTestCachingClient(m, CacheConfig{TTL: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.FetchPokemonContext(ctx, "25")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
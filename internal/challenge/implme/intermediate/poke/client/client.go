@@ -1,12 +1,28 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
-	"github.com/mtslzr/pokeapi-go"
 	"github.com/mtslzr/pokeapi-go/structs"
 )
 
+const (
+	apiURL              = "https://pokeapi.co/api/v2/"
+	defaultTimeout      = 10 * time.Second
+	defaultCacheTTL     = 5 * time.Minute // defaultCacheTTL matches the default expiration of the pokeapi-go cache.
+	defaultCacheEntries = 1000
+)
+
+// ErrNotFound is returned when no Pokémon matches the requested ID.
+var ErrNotFound = errors.New("pokemon not found")
+
 type Poke struct {
 	Err      error
 	Name     string
@@ -18,17 +34,117 @@ type PokeClient interface {
 	FetchPokemon(ID string) (*structs.Pokemon, error)
 }
 
+// ContextPokeClient is a PokeClient whose lookups can be cancelled or given a deadline through their context.
+//
+//go:generate  go run github.com/vektra/mockery/v2@v2.20.0 --with-expecter=true --name ContextPokeClient
+type ContextPokeClient interface {
+	FetchPokemonContext(ctx context.Context, ID string) (*structs.Pokemon, error)
+}
+
+// New creates a PokeClient that caches the fetched Pokémon for defaultCacheTTL, like the pokeapi-go library it
+// replaces.
 func New() PokeClient {
-	return &pokeClient{}
+	return NewCachingClient(newPokeClient(nil), CacheConfig{TTL: defaultCacheTTL, MaxEntries: defaultCacheEntries})
+}
+
+// NewContextClient creates a ContextPokeClient that uses httpClient, or a client with a default timeout if nil.
+func NewContextClient(httpClient *http.Client) ContextPokeClient {
+	return newPokeClient(httpClient)
+}
+
+func newPokeClient(httpClient *http.Client) *pokeClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return &pokeClient{httpClient: httpClient}
 }
 
 type pokeClient struct {
+	httpClient *http.Client
+	baseURL    string
 }
 
 func (p pokeClient) FetchPokemon(ID string) (*structs.Pokemon, error) {
-	pokemon, err := pokeapi.Pokemon(strings.TrimSpace(ID))
+	return p.FetchPokemonContext(context.Background(), ID)
+}
+
+// FetchPokemonContext fetches a Pokémon by name or ID, the context deadline and cancellation apply to the HTTP call.
+func (p pokeClient) FetchPokemonContext(ctx context.Context, ID string) (*structs.Pokemon, error) {
+	endpoint := p.url() + "pokemon/" + url.PathEscape(strings.TrimSpace(ID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, ID)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetch pokemon %q: unexpected status %s", ID, resp.Status)
+	}
+
+	var pokemon structs.Pokemon
+	if err := json.NewDecoder(resp.Body).Decode(&pokemon); err != nil {
+		return nil, fmt.Errorf("fetch pokemon %q: %w", ID, err)
+	}
 	return &pokemon, nil
 }
+
+func (p pokeClient) url() string {
+	if p.baseURL != "" {
+		return p.baseURL
+	}
+	return apiURL
+}
+
+// WithContext adapts a PokeClient to the ContextPokeClient interface. The underlying call cannot be cancelled, but
+// FetchPokemonContext returns as soon as the context is done.
+func WithContext(pokeClient PokeClient) ContextPokeClient {
+	return contextAdapter{pokeClient: pokeClient}
+}
+
+// WithoutContext adapts a ContextPokeClient to the PokeClient interface, lookups run with a background context.
+func WithoutContext(pokeClient ContextPokeClient) PokeClient {
+	return legacyAdapter{pokeClient: pokeClient}
+}
+
+type contextAdapter struct {
+	pokeClient PokeClient
+}
+
+func (a contextAdapter) FetchPokemonContext(ctx context.Context, ID string) (*structs.Pokemon, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		pokemon *structs.Pokemon
+		err     error
+	}
+	done := make(chan result, 1) // Buffered channel so that the goroutine never blocks once the caller gave up.
+	go func() {
+		pokemon, err := a.pokeClient.FetchPokemon(ID)
+		done <- result{pokemon: pokemon, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.pokemon, r.err
+	}
+}
+
+type legacyAdapter struct {
+	pokeClient ContextPokeClient
+}
+
+func (a legacyAdapter) FetchPokemon(ID string) (*structs.Pokemon, error) {
+	return a.pokeClient.FetchPokemonContext(context.Background(), ID)
+}
@@ -1,11 +1,17 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/mtslzr/pokeapi-go/structs"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/internal/challenge/implme/intermediate/poke/client/mocks"
 )
 
 func Test_pokeClient_FetchPokemon(t *testing.T) {
@@ -63,7 +69,7 @@ func Test_pokeClient_FetchPokemon(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := pokeClient{}
+			p := newPokeClient(nil)
 			got, err := p.FetchPokemon(tt.args.ID)
 			if !tt.wantErr(t, err, fmt.Sprintf("FetchPokemon(%v)", tt.args.ID)) {
 				return
@@ -74,3 +80,92 @@ func Test_pokeClient_FetchPokemon(t *testing.T) {
 		})
 	}
 }
+
+func Test_pokeClient_FetchPokemonContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pokemon/25", "/pokemon/pikachu":
+			_, _ = fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+		case "/pokemon/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		case "/pokemon/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		ID         string
+		timeout    time.Duration
+		want       *structs.Pokemon
+		wantErr    error
+		wantErrMsg string
+	}{
+		{name: "Fetch valid pokemon by ID", ID: "25", want: &structs.Pokemon{ID: 25, Name: "pikachu"}},
+		{name: "Fetch valid pokemon by name", ID: " pikachu ", want: &structs.Pokemon{ID: 25, Name: "pikachu"}},
+		{name: "Fetch with invalid ID", ID: "invalid", wantErr: ErrNotFound},
+		{name: "Fetch with non-numeric ID", ID: "pika!", wantErr: ErrNotFound},
+		{name: "Deadline is honoured", ID: "slow", timeout: 10 * time.Millisecond, wantErr: context.DeadlineExceeded},
+		{name: "Unexpected status", ID: "broken", wantErrMsg: `fetch pokemon "broken": unexpected status 500 Internal Server Error`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			p := pokeClient{httpClient: server.Client(), baseURL: server.URL + "/"}
+			got, err := p.FetchPokemonContext(ctx, tt.ID)
+
+			switch {
+			case tt.wantErrMsg != "":
+				assert.EqualError(t, err, tt.wantErrMsg)
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	m := mocks.NewPokeClient(t)
+	m.EXPECT().FetchPokemon("25").Return(&structs.Pokemon{Name: "pikachu"}, nil).Once()
+	m.EXPECT().FetchPokemon("slow").RunAndReturn(func(string) (*structs.Pokemon, error) {
+		<-release // ignores any deadline
+		return nil, nil
+	}).Once()
+	adapted := WithContext(m)
+
+	got, err := adapted.FetchPokemonContext(context.Background(), "25")
+	assert.NoError(t, err)
+	assert.Equal(t, "pikachu", got.Name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = adapted.FetchPokemonContext(ctx, "slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithoutContext(t *testing.T) {
+	m := mocks.NewContextPokeClient(t)
+	m.EXPECT().FetchPokemonContext(context.Background(), "25").Return(&structs.Pokemon{Name: "pikachu"}, nil).Once()
+
+	got, err := WithoutContext(m).FetchPokemon("25")
+	assert.NoError(t, err)
+	assert.Equal(t, "pikachu", got.Name)
+}
@@ -0,0 +1,93 @@
+// Code generated by mockery v2.20.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	structs "github.com/mtslzr/pokeapi-go/structs"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ContextPokeClient is an autogenerated mock type for the ContextPokeClient type
+type ContextPokeClient struct {
+	mock.Mock
+}
+
+type ContextPokeClient_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *ContextPokeClient) EXPECT() *ContextPokeClient_Expecter {
+	return &ContextPokeClient_Expecter{mock: &_m.Mock}
+}
+
+// FetchPokemonContext provides a mock function with given fields: ctx, ID
+func (_m *ContextPokeClient) FetchPokemonContext(ctx context.Context, ID string) (*structs.Pokemon, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 *structs.Pokemon
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*structs.Pokemon, error)); ok {
+		return rf(ctx, ID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *structs.Pokemon); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*structs.Pokemon)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ContextPokeClient_FetchPokemonContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FetchPokemonContext'
+type ContextPokeClient_FetchPokemonContext_Call struct {
+	*mock.Call
+}
+
+// FetchPokemonContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ID string
+func (_e *ContextPokeClient_Expecter) FetchPokemonContext(ctx interface{}, ID interface{}) *ContextPokeClient_FetchPokemonContext_Call {
+	return &ContextPokeClient_FetchPokemonContext_Call{Call: _e.mock.On("FetchPokemonContext", ctx, ID)}
+}
+
+func (_c *ContextPokeClient_FetchPokemonContext_Call) Run(run func(ctx context.Context, ID string)) *ContextPokeClient_FetchPokemonContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *ContextPokeClient_FetchPokemonContext_Call) Return(_a0 *structs.Pokemon, _a1 error) *ContextPokeClient_FetchPokemonContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ContextPokeClient_FetchPokemonContext_Call) RunAndReturn(run func(context.Context, string) (*structs.Pokemon, error)) *ContextPokeClient_FetchPokemonContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+type mockConstructorTestingTNewContextPokeClient interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewContextPokeClient creates a new instance of ContextPokeClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewContextPokeClient(t mockConstructorTestingTNewContextPokeClient) *ContextPokeClient {
+	mock := &ContextPokeClient{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"time"
+
 	"github.com/romangurevitch/gophercon2023/internal/challenge/implme/intermediate/poke/app"
 	"github.com/romangurevitch/gophercon2023/internal/challenge/implme/intermediate/poke/client"
 )
 
 func main() {
-	pokeAPP := app.NewPokeApp(client.New())
+	// The app fetches on every keystroke, so repeated and concurrent lookups are served from a cache.
+	pokeClient := client.NewCachingClient(client.NewContextClient(nil), client.CacheConfig{
+		TTL:         10 * time.Minute,
+		NotFoundTTL: time.Minute,
+		MaxEntries:  1000,
+	})
+	pokeAPP := app.NewPokeApp(pokeClient)
 	pokeAPP.Start()
 }
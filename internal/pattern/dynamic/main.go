@@ -2,76 +2,23 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
-	"sync"
 	"time"
 
-	"github.com/mtslzr/pokeapi-go"
 	"golang.org/x/time/rate"
-)
-
-// Job holds information about each job.
-type Job[T any] struct {
-	ID    int
-	Value T
-}
-
-// Result holds information about each result.
-type Result[T any, U any] struct {
-	Job   Job[T]
-	Value U
-	Err   error
-}
-
-// ProcessFunc defines a function type for processing a value of type T to produce a value of type U, in a context-aware manner.
-type ProcessFunc[T any, U any] func(context.Context, T) (U, error)
-
-// NewRateLimited creates a rate-limited worker pool.
-func NewRateLimited[T any, U any](ctx context.Context, limiter *rate.Limiter, jobs <-chan Job[T], processFunc ProcessFunc[T, U]) <-chan Result[T, U] {
-	results := make(chan Result[T, U], limiter.Burst())
-
-	go func() {
-		wg := sync.WaitGroup{}
-		defer func() {
-			// Close the results channel once all workers are done.
-			wg.Wait()
-			close(results)
-		}()
-
-		for {
-			select {
-			case <-ctx.Done():
-				slog.Info("shutting down goroutine", "reason", ctx.Err())
-				return
-			case job, ok := <-jobs:
-				if !ok {
-					return // jobs channel closed, exit worker
-				}
-				if err := limiter.Wait(context.Background()); err != nil { // context shutdown is handled elsewhere.
-					results <- Result[T, U]{Job: job, Err: err}
-					return
-				}
-				wg.Add(1)
-				go func(job Job[T]) {
-					defer wg.Done()
-					value, err := processFunc(ctx, job.Value)
-					results <- Result[T, U]{Job: job, Value: value, Err: err}
-				}(job)
-			}
-		}
 
-	}()
-
-	return results
-}
+	"github.com/romangurevitch/gophercon2023/internal/challenge/implme/intermediate/poke/client"
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/dynamic"
+)
 
-var ErrNegativeValue = errors.New("negative value")
+// pokeClient is shared by the workers, so that they reuse the connections to pokeapi.
+var pokeClient = client.NewContextClient(nil)
 
-// FetchPokemonName just returns the Pokemon name as a string.
+// FetchPokemonName just returns the Pokemon name as a string, the lookup is cancelled once ctx is done.
 func FetchPokemonName(ctx context.Context, pokemonID int) (string, error) {
-	pokemon, err := pokeapi.Pokemon(fmt.Sprint(pokemonID))
+	pokemon, err := pokeClient.FetchPokemonContext(ctx, fmt.Sprint(pokemonID))
 	if err != nil {
 		return "", err
 	}
@@ -82,11 +29,34 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	jobs := make(chan Job[int])
-	limiter := rate.NewLimiter(rate.Every(100*time.Millisecond), 10) // Limit to 2 jobs per second with a burst of 10
-
-	// Create a worker pool with 3 workers.
-	results := NewRateLimited(ctx, limiter, jobs, FetchPokemonName)
+	jobs := make(chan pattern.Job[int])
+	// Start at 10 jobs per second with a burst of 10, backing off when pokeapi fails or slows down.
+	limiter := dynamic.NewAdaptiveLimiter(dynamic.AdaptiveConfig{
+		Initial:          rate.Every(100 * time.Millisecond),
+		Min:              rate.Every(time.Second),
+		Max:              rate.Every(20 * time.Millisecond),
+		Burst:            10,
+		Increase:         0.1,
+		LatencyThreshold: time.Second,
+		Cooldown:         time.Second,
+		OnRateChange: func(limit rate.Limit) {
+			slog.Info("Rate changed", "jobsPerSecond", float64(limit))
+		},
+	})
+
+	// Create a rate-limited worker pool, retrying failed lookups.
+	retry := pattern.RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, Jitter: 0.2}
+	// Lookups that still fail after their retries go to a dead-letter channel, read while the pool runs.
+	deadLetters := make(chan pattern.DeadLetter[int])
+	deadLettersDone := make(chan struct{})
+	go func() {
+		defer close(deadLettersDone)
+		for letter := range deadLetters {
+			slog.Warn("Dead letter", "jobID", letter.Job.ID, "attempts", letter.Attempts, "error", letter.Err)
+		}
+	}()
+	results := dynamic.NewRateLimited(ctx, limiter, jobs, FetchPokemonName,
+		pattern.WithRetry[int](retry), pattern.WithDeadLetter(deadLetters))
 
 	// This goroutine sends a new jobs.
 	go func() {
@@ -96,18 +66,21 @@ func main() {
 				close(jobs)
 				return
 			default:
-				jobs <- Job[int]{ID: i, Value: i}
+				jobs <- pattern.Job[int]{ID: i, Value: i}
 			}
 		}
 	}()
 
-	// Process the results.
+	// Process the results, a failed lookup does not stop the others: only the jobs cut short by the timeout fail here.
 	for result := range results {
 		if result.Err != nil {
-			slog.Error("Error processing job", "jobID", result.Job.ID, "error", result.Err)
-			cancel()
+			slog.Error("Error processing job", "jobID", result.Job.ID, "attempts", result.Attempts, "error", result.Err)
 			continue
 		}
 		slog.Info("Result for job", "jobID", result.Job.ID, "result", result.Value)
 	}
+
+	// All workers are done once the results channel is closed.
+	close(deadLetters)
+	<-deadLettersDone
 }
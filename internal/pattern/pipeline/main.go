@@ -7,56 +7,27 @@ import (
 
 	"github.com/mtslzr/pokeapi-go"
 	"github.com/mtslzr/pokeapi-go/structs"
-)
-
-// Result is a generic type to encapsulate the result of an operation.
-type Result[T any] struct {
-	Value T
-	Err   error
-}
 
-// ProcessFunc defines a function type that processes a Result of type T and produces a Result of type U.
-type ProcessFunc[T any, U any] func(context.Context, Result[T]) Result[U]
-
-// Pipe reads Results of type T from inCh, processes them using the provided operation op,
-// and sends the Results of type U on a new channel.
-func Pipe[T any, U any](ctx context.Context, inCh <-chan Result[T], processFunc ProcessFunc[T, U]) <-chan Result[U] {
-	outCh := make(chan Result[U])
-	go func() {
-		defer close(outCh) // Ensure the channel is closed when the goroutine exits.
-		for {
-			select {
-			case <-ctx.Done():
-				slog.Info("shutting down goroutine", "reason", ctx.Err())
-				return
-			case in, ok := <-inCh:
-				if !ok {
-					return // jobs channel closed, exit worker
-				}
-				outCh <- processFunc(ctx, in) // Process the result using processFunc and send it on the output channel.
-			}
-		}
-	}()
-	return outCh
-}
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/pipeline"
+)
 
 // fetchPokemon fetches Pokémon data for a given ID.
-func fetchPokemon(_ context.Context, result Result[int]) Result[structs.Pokemon] {
+func fetchPokemon(_ context.Context, result pipeline.Result[int]) pipeline.Result[structs.Pokemon] {
 	pokemon, err := pokeapi.Pokemon(fmt.Sprint(result.Value))
 	if err != nil {
-		return Result[structs.Pokemon]{Err: err}
+		return pipeline.Result[structs.Pokemon]{Err: err}
 	}
-	return Result[structs.Pokemon]{Value: pokemon}
+	return pipeline.Result[structs.Pokemon]{Value: pokemon}
 }
 
 // printPokemonName processes fetched Pokémon data to extract and print the Pokémon's name.
-func printPokemonName(_ context.Context, result Result[structs.Pokemon]) Result[bool] {
+func printPokemonName(_ context.Context, result pipeline.Result[structs.Pokemon]) pipeline.Result[bool] {
 	if result.Err != nil {
 		slog.Error("Error processing job", "error", result.Err)
-		return Result[bool]{Err: result.Err}
+		return pipeline.Result[bool]{Err: result.Err}
 	}
 	slog.Info("Pokemon Name", "name", result.Value.Name)
-	return Result[bool]{Value: true}
+	return pipeline.Result[bool]{Value: true}
 }
 
 func main() {
@@ -65,23 +36,23 @@ func main() {
 
 	// Define maximum number of Pokémon to fetch.
 	maxPokemon := 5
+	ids := make([]int, 0, maxPokemon)
+	for i := 1; i <= maxPokemon; i++ {
+		ids = append(ids, i)
+	}
 
-	// Create the pipeline.
-	inputCh := make(chan Result[int])
-	fetchCh := Pipe(ctx, inputCh, fetchPokemon)
-	processCh := Pipe(ctx, fetchCh, printPokemonName)
-
-	go func() {
-		for i := 1; i <= maxPokemon; i++ {
-			inputCh <- Result[int]{Value: i}
-		}
-		close(inputCh)
-	}()
+	// Create the pipeline, fetching is I/O bound so it gets several workers, keeping the input order.
+	p := pipeline.New(ctx)
+	fetched := pipeline.Then(pipeline.FromValues(p, ids...), fetchPokemon, pipeline.WithWorkers(3), pipeline.WithOrder())
+	processed := pipeline.Then(fetched, printPokemonName)
 
 	// Wait for the last stage to complete.
-	for result := range processCh {
+	for result := range processed.Out() {
 		if result.Err != nil {
 			slog.Error("Error", "error", result.Err)
 		}
 	}
+	if err := p.Wait(); err != nil {
+		slog.Error("Pipeline stopped", "error", err)
+	}
 }
@@ -4,59 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"sync"
 
 	"github.com/mtslzr/pokeapi-go"
 	"github.com/mtslzr/pokeapi-go/structs"
-)
-
-type Result[T any] struct {
-	Value T
-	Err   error
-}
-
-type PubSub[T any] struct {
-	subscribers sync.Map // key: topic (string), value: []chan Result[T]
-}
-
-func NewPubSub[T any]() *PubSub[T] {
-	return &PubSub[T]{}
-}
-
-func (ps *PubSub[T]) Subscribe(topic string, ch chan Result[T]) {
-	subscribers, _ := ps.subscribers.LoadOrStore(topic, []chan Result[T]{})
-	// Append to the existing slice of channels
-	ps.subscribers.Store(topic, append(subscribers.([]chan Result[T]), ch))
-}
 
-func (ps *PubSub[T]) Unsubscribe(topic string, ch chan Result[T]) {
-	value, ok := ps.subscribers.Load(topic)
-	if !ok {
-		return // no subscribers for this topic
-	}
-	subscribers := value.([]chan Result[T])
-	for i, subscriber := range subscribers {
-		if subscriber == ch {
-			// Remove the subscriber from the slice
-			ps.subscribers.Store(topic, append(subscribers[:i], subscribers[i+1:]...))
-			return
-		}
-	}
-}
-
-func (ps *PubSub[T]) Publish(topic string, message T) {
-	value, ok := ps.subscribers.Load(topic)
-	if !ok {
-		return // no subscribers for this topic
-	}
-	subscribers := value.([]chan Result[T])
-	for _, ch := range subscribers {
-		select {
-		case ch <- Result[T]{Value: message}:
-		default: // if the channel is not ready to receive, move on to the next subscriber
-		}
-	}
-}
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/pubsub"
+)
 
 // fetchPokemon fetches Pokémon data for a given ID.
 func fetchPokemon(_ context.Context, pokeID int) (structs.Pokemon, error) {
@@ -64,10 +17,10 @@ func fetchPokemon(_ context.Context, pokeID int) (structs.Pokemon, error) {
 }
 
 func main() {
-	pubSub := NewPubSub[structs.Pokemon]()
+	pubSub := pubsub.NewPubSub[structs.Pokemon]()
 	topicName := "pokemon"
-	subscriber1 := make(chan Result[structs.Pokemon], 1)
-	subscriber2 := make(chan Result[structs.Pokemon], 1)
+	subscriber1 := make(chan pubsub.Result[structs.Pokemon], 1)
+	subscriber2 := make(chan pubsub.Result[structs.Pokemon], 1)
 
 	pubSub.Subscribe(topicName, subscriber1)
 	pubSub.Subscribe(topicName, subscriber2)
@@ -76,7 +29,9 @@ func main() {
 	if err != nil {
 		slog.Error("Error fetching Pokemon", "error", err)
 	}
-	pubSub.Publish(topicName, poke)
+	if err := pubSub.Publish(topicName, poke); err != nil {
+		slog.Error("Error publishing Pokemon", "error", err)
+	}
 
 	slog.Info("Received message on subscriber 1", "topic", topicName, "pokemon name", (<-subscriber1).Value.Name)
 	slog.Info("Received message on subscriber 2", "topic", topicName, "pokemon name", (<-subscriber2).Value.Name)
@@ -4,64 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"sync"
 	"time"
 
-	"github.com/mtslzr/pokeapi-go"
+	"github.com/romangurevitch/gophercon2023/internal/challenge/implme/intermediate/poke/client"
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/workerpool"
 )
 
-// Job holds information about each job.
-type Job[T any] struct {
-	ID    int
-	Value T
-}
-
-// Result holds information about each result.
-type Result[T any, U any] struct {
-	Job   Job[T]
-	Value U
-	Err   error
-}
-
-// ProcessFunc defines a function type for processing a value of type T to produce a value of type U, in a context-aware manner.
-type ProcessFunc[T any, U any] func(context.Context, T) (U, error)
-
-// Worker function processes jobs and produces results.
-func worker[T any, U any](ctx context.Context, jobs <-chan Job[T], results chan<- Result[T, U], processFunc ProcessFunc[T, U]) {
-	for {
-		select {
-		case <-ctx.Done():
-			return // context cancelled, exit worker
-		case job, ok := <-jobs:
-			if !ok {
-				return // jobs channel closed, exit worker
-			}
-			value, err := processFunc(ctx, job.Value)
-			results <- Result[T, U]{Job: job, Value: value, Err: err}
-		}
-	}
-}
+// pokeClient is shared by the workers, so that they reuse the connections to pokeapi.
+var pokeClient = client.NewContextClient(nil)
 
-// CreateWorkerPool creates a pool of workers.
-func CreateWorkerPool[T any, U any](ctx context.Context, numWorkers int, jobs <-chan Job[T], results chan<- Result[T, U], process ProcessFunc[T, U]) {
-	wg := sync.WaitGroup{}
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			worker(ctx, jobs, results, process)
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-}
-
-// FetchPokemonName just returns the Pokemon name as a string.
-func FetchPokemonName(_ context.Context, pokemonID int) (string, error) {
-	pokemon, err := pokeapi.Pokemon(fmt.Sprint(pokemonID))
+// FetchPokemonName just returns the Pokemon name as a string, the lookup is cancelled once ctx is done.
+func FetchPokemonName(ctx context.Context, pokemonID int) (string, error) {
+	pokemon, err := pokeClient.FetchPokemonContext(ctx, fmt.Sprint(pokemonID))
 	if err != nil {
 		return "", err
 	}
@@ -72,11 +27,22 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	jobs := make(chan Job[int])
-	results := make(chan Result[int, string])
+	jobs := make(chan pattern.Job[int])
+	results := make(chan pattern.Result[int, string])
 
-	// Create a worker pool with 3 workers.
-	CreateWorkerPool(ctx, 3, jobs, results, FetchPokemonName)
+	// Create a worker pool with 3 workers, retrying failed lookups.
+	retry := pattern.RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, Jitter: 0.2}
+	// Lookups that still fail after their retries go to a dead-letter channel, read while the pool runs.
+	deadLetters := make(chan pattern.DeadLetter[int])
+	deadLettersDone := make(chan struct{})
+	go func() {
+		defer close(deadLettersDone)
+		for letter := range deadLetters {
+			slog.Warn("Dead letter", "jobID", letter.Job.ID, "attempts", letter.Attempts, "error", letter.Err)
+		}
+	}()
+	workerpool.CreateWorkerPool(ctx, 3, jobs, results, FetchPokemonName,
+		pattern.WithRetry[int](retry), pattern.WithDeadLetter(deadLetters))
 
 	// This goroutine sends a new job every second.
 	go func() {
@@ -86,18 +52,21 @@ func main() {
 				close(jobs)
 				return
 			default:
-				jobs <- Job[int]{ID: i, Value: i}
+				jobs <- pattern.Job[int]{ID: i, Value: i}
 			}
 		}
 	}()
 
-	// Process the results.
+	// Process the results, a failed lookup does not stop the others: only the jobs cut short by the timeout fail here.
 	for result := range results {
 		if result.Err != nil {
-			slog.Error("Error processing job", "jobID", result.Job.ID, "error", result.Err)
-			cancel()
+			slog.Error("Error processing job", "jobID", result.Job.ID, "attempts", result.Attempts, "error", result.Err)
 			continue
 		}
 		slog.Info("Result for job", "jobID", result.Job.ID, "result", result.Value)
 	}
+
+	// All workers are done once the results channel is closed.
+	close(deadLetters)
+	<-deadLettersDone
 }
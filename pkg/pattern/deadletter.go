@@ -0,0 +1,47 @@
+package pattern
+
+import (
+	"context"
+	"time"
+)
+
+// DeadLetter holds a job that permanently failed, once it exhausted its retries.
+type DeadLetter[T any] struct {
+	Job            Job[T]
+	Err            error // Err joins the errors of every attempt, in order.
+	Attempts       int
+	FirstAttemptAt time.Time
+	FailedAt       time.Time
+}
+
+// WithDeadLetter routes jobs that permanently fail to sink instead of the results channel.
+// Jobs failing because the pool context is done are still delivered as results.
+// The worker that failed a job waits until the sink has room, or the pool context is done, so the sink must be
+// buffered or read concurrently. The sink is never closed by the pool.
+func WithDeadLetter[T any](sink chan<- DeadLetter[T]) Option[T] {
+	return func(o *Options[T]) {
+		o.deadLetter = sink
+	}
+}
+
+// ReplayJobs returns the original jobs of a dead-letter batch, e.g. to feed them to FanOut again.
+func ReplayJobs[T any](batch []DeadLetter[T]) []Job[T] {
+	jobs := make([]Job[T], 0, len(batch))
+	for _, letter := range batch {
+		jobs = append(jobs, letter.Job)
+	}
+	return jobs
+}
+
+// Replay sends the original jobs of a dead-letter batch back to a pool's jobs channel.
+// It returns the context error if the context is done before the whole batch was sent.
+func Replay[T any](ctx context.Context, batch []DeadLetter[T], jobs chan<- Job[T]) error {
+	for _, letter := range batch {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case jobs <- letter.Job:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package pattern
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessor_ProcessDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	sink := make(chan DeadLetter[int], 1)
+	processor := NewProcessor(failingFunc(3, ErrTransient),
+		WithRetry[int](RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithDeadLetter(sink),
+	)
+
+	job := Job[int]{ID: 1, Value: 21}
+	_, ok := processor.Process(ctx, job)
+	assert.False(t, ok, "expected the job to be dead-lettered")
+
+	letter := <-sink
+	assert.Equal(t, job, letter.Job)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.ErrorIs(t, letter.Err, ErrTransient)
+	assert.Len(t, letter.Err.(interface{ Unwrap() []error }).Unwrap(), 3)
+	assert.False(t, letter.FailedAt.Before(letter.FirstAttemptAt))
+
+	// Successful jobs keep flowing to the results.
+	result, ok := processor.Process(ctx, job)
+	assert.True(t, ok)
+	assert.Equal(t, Result[int, int]{Job: job, Value: 42, Attempts: 1}, result)
+}
+
+func TestProcessor_ProcessDeadLetterCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // a cancelled pool reports failures as results
+
+	sink := make(chan DeadLetter[int], 1)
+	processor := NewProcessor(failingFunc(1, context.Canceled), WithDeadLetter(sink))
+
+	result, ok := processor.Process(ctx, Job[int]{ID: 1, Value: 21})
+	assert.True(t, ok)
+	assert.ErrorIs(t, result.Err, context.Canceled)
+	assert.Empty(t, sink)
+}
+
+func TestReplay(t *testing.T) {
+	batch := []DeadLetter[int]{
+		{Job: Job[int]{ID: 1, Value: 1}, Err: ErrTransient, Attempts: 3},
+		{Job: Job[int]{ID: 2, Value: 2}, Err: ErrTransient, Attempts: 3},
+	}
+	want := []Job[int]{{ID: 1, Value: 1}, {ID: 2, Value: 2}}
+	assert.Equal(t, want, ReplayJobs(batch))
+
+	t.Run("Replay into jobs channel", func(t *testing.T) {
+		jobs := make(chan Job[int], len(batch))
+		assert.NoError(t, Replay(context.Background(), batch, jobs))
+		close(jobs)
+
+		var got []Job[int]
+		for job := range jobs {
+			got = append(got, job)
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		jobs := make(chan Job[int])
+		err := Replay(ctx, batch, jobs)
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
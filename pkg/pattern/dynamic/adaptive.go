@@ -0,0 +1,147 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter limits the rate at which jobs are started, *rate.Limiter implements it.
+type Limiter interface {
+	Wait(ctx context.Context) error
+	Burst() int
+}
+
+// Observer is implemented by limiters that adapt to the outcome of each processed job.
+type Observer interface {
+	Observe(latency time.Duration, err error)
+}
+
+// AdaptiveConfig configures an AdaptiveLimiter.
+type AdaptiveConfig struct {
+	// Initial is the starting rate, it defaults to Max.
+	Initial rate.Limit
+	// Min and Max bound the rate, an unset Max leaves the rate unbounded above. With both Max and Initial unset,
+	// the rate starts at rate.Inf and never adapts.
+	Min, Max rate.Limit
+	// Burst is the token bucket size, minimum one.
+	Burst int
+	// Increase is added to the rate after every job completing without a sign of overload, it defaults to a tenth
+	// of Initial so that the rate recovers after a decrease.
+	Increase rate.Limit
+	// Decrease multiplies the rate on overload, between 0 and 1, it defaults to 0.5.
+	Decrease float64
+	// LatencyThreshold marks jobs slower than it as a sign of overload, zero disables it.
+	LatencyThreshold time.Duration
+	// IsOverload reports whether an error is a sign of overload, such as a 429 response.
+	// nil treats every error except context errors as overload.
+	IsOverload func(error) bool
+	// Cooldown is the minimum time between two decreases, so that a burst of failures
+	// from requests already in flight only counts once.
+	Cooldown time.Duration
+	// OnRateChange, if set, is called with the new rate every time it changes, e.g. to export it as a metric.
+	// It is called without holding the limiter lock, so changes observed concurrently may be reported out of order.
+	OnRateChange func(rate.Limit)
+}
+
+// AdaptiveLimiter is an AIMD (additive increase, multiplicative decrease) rate limiter:
+// it cuts the rate when jobs fail with overload errors or run slower than the latency threshold,
+// and probes back up while jobs succeed.
+type AdaptiveLimiter struct {
+	config  AdaptiveConfig
+	limiter *rate.Limiter
+
+	mu           sync.Mutex
+	lastDecrease time.Time
+}
+
+// NewAdaptiveLimiter creates an AdaptiveLimiter.
+func NewAdaptiveLimiter(config AdaptiveConfig) *AdaptiveLimiter {
+	if config.Max <= 0 {
+		config.Max = rate.Inf
+	}
+	config.Min = min(max(config.Min, 0), config.Max)
+	if config.Initial <= 0 {
+		config.Initial = config.Max
+	}
+	config.Initial = min(max(config.Initial, config.Min), config.Max)
+	if config.Increase <= 0 {
+		config.Increase = config.Initial / 10
+	}
+	if config.Decrease <= 0 || config.Decrease >= 1 {
+		config.Decrease = 0.5
+	}
+
+	return &AdaptiveLimiter{
+		config:  config,
+		limiter: rate.NewLimiter(config.Initial, max(config.Burst, 1)),
+	}
+}
+
+// Wait blocks until a job is allowed to start or the context is done.
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	return l.limiter.Wait(ctx)
+}
+
+// Burst returns the token bucket size.
+func (l *AdaptiveLimiter) Burst() int {
+	return l.limiter.Burst()
+}
+
+// Rate returns the current rate.
+func (l *AdaptiveLimiter) Rate() rate.Limit {
+	return l.limiter.Limit()
+}
+
+// Observe adapts the rate to the outcome of a job. Context errors carry no signal and are ignored.
+func (l *AdaptiveLimiter) Observe(latency time.Duration, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	next, changed := l.adapt(latency, err)
+	if changed && l.config.OnRateChange != nil {
+		l.config.OnRateChange(next)
+	}
+}
+
+// adapt sets the rate according to the outcome of a job, and reports the new rate and whether it changed.
+func (l *AdaptiveLimiter) adapt(latency time.Duration, err error) (rate.Limit, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := l.limiter.Limit()
+	next := current
+	if l.overloaded(latency, err) {
+		if time.Since(l.lastDecrease) < l.config.Cooldown {
+			return current, false
+		}
+		l.lastDecrease = time.Now()
+		next = max(current*rate.Limit(l.config.Decrease), l.config.Min)
+	} else if current != rate.Inf {
+		next = min(current+l.config.Increase, l.config.Max)
+	}
+
+	if next == current {
+		return current, false
+	}
+	l.limiter.SetLimit(next)
+	return next, true
+}
+
+// overloaded reports whether the outcome of a job is a sign of overload.
+func (l *AdaptiveLimiter) overloaded(latency time.Duration, err error) bool {
+	if l.config.LatencyThreshold > 0 && latency > l.config.LatencyThreshold {
+		return true
+	}
+	if err == nil {
+		return false
+	}
+	if l.config.IsOverload != nil {
+		return l.config.IsOverload(err)
+	}
+	return true
+}
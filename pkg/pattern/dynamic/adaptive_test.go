@@ -0,0 +1,129 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+var (
+	ErrTooManyRequests = errors.New("429 too many requests")
+	ErrNotFound        = errors.New("404 not found")
+)
+
+func TestAdaptiveLimiter_Observe(t *testing.T) {
+	type observation struct {
+		latency time.Duration
+		err     error
+	}
+	config := AdaptiveConfig{
+		Initial:          10,
+		Min:              2,
+		Max:              12,
+		Increase:         1,
+		Decrease:         0.5,
+		LatencyThreshold: 100 * time.Millisecond,
+		IsOverload:       func(err error) bool { return errors.Is(err, ErrTooManyRequests) },
+	}
+
+	tests := []struct {
+		name         string
+		observations []observation
+		want         rate.Limit
+	}{
+		{name: "Additive increase", observations: []observation{{}, {}}, want: 12},
+		{name: "Capped at max", observations: []observation{{}, {}, {}, {}}, want: 12},
+		{name: "Multiplicative decrease on overload error", observations: []observation{{err: ErrTooManyRequests}}, want: 5},
+		{name: "Multiplicative decrease on latency", observations: []observation{{latency: time.Second}}, want: 5},
+		{name: "Floored at min", observations: []observation{{err: ErrTooManyRequests}, {err: ErrTooManyRequests}, {err: ErrTooManyRequests}}, want: 2},
+		{name: "Other errors probe up", observations: []observation{{err: ErrNotFound}}, want: 11},
+		{name: "Context errors are ignored", observations: []observation{{err: context.Canceled}}, want: 10},
+		{name: "Recovers after overload", observations: []observation{{err: ErrTooManyRequests}, {}, {}, {}}, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewAdaptiveLimiter(config)
+			for _, o := range tt.observations {
+				limiter.Observe(o.latency, o.err)
+			}
+			assert.Equal(t, tt.want, limiter.Rate())
+		})
+	}
+}
+
+func TestAdaptiveLimiter_Cooldown(t *testing.T) {
+	var changes []rate.Limit
+	limiter := NewAdaptiveLimiter(AdaptiveConfig{
+		Initial:      8,
+		Max:          8,
+		Cooldown:     time.Hour,
+		OnRateChange: func(limit rate.Limit) { changes = append(changes, limit) },
+	})
+
+	// A burst of failures only halves the rate once.
+	for i := 0; i < 5; i++ {
+		limiter.Observe(0, ErrTooManyRequests)
+	}
+	assert.Equal(t, rate.Limit(4), limiter.Rate())
+	assert.Equal(t, []rate.Limit{4}, changes)
+}
+
+func TestAdaptiveLimiter_DefaultIncreaseRecovers(t *testing.T) {
+	limiter := NewAdaptiveLimiter(AdaptiveConfig{Initial: 10, Max: 10})
+
+	limiter.Observe(0, ErrTooManyRequests)
+	assert.Equal(t, rate.Limit(5), limiter.Rate())
+	for i := 0; i < 5; i++ {
+		limiter.Observe(0, nil)
+	}
+	assert.Equal(t, rate.Limit(10), limiter.Rate(), "the rate should recover without configuring Increase")
+}
+
+func TestAdaptiveLimiter_OnRateChangeWithoutLock(t *testing.T) {
+	var limiter *AdaptiveLimiter
+	limiter = NewAdaptiveLimiter(AdaptiveConfig{
+		Initial: 8,
+		Max:     8,
+		// Observing from the callback would deadlock if it was called with the lock held.
+		OnRateChange: func(rate.Limit) { limiter.Observe(0, nil) },
+	})
+
+	limiter.Observe(0, ErrTooManyRequests)
+	assert.Equal(t, rate.Limit(8), limiter.Rate(), "each change triggers another increase, up to Max")
+}
+
+func TestNewRateLimited_Adaptive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	limiter := NewAdaptiveLimiter(AdaptiveConfig{Initial: 1000, Min: 10, Max: 1000, Burst: 1})
+
+	// The upstream starts throttling after a few calls.
+	var calls atomic.Int64
+	throttled := func(ctx context.Context, value int) (int, error) {
+		if calls.Add(1) > 3 {
+			return 0, ErrTooManyRequests
+		}
+		return squareNonNegative(ctx, value)
+	}
+
+	numJobs := 6
+	jobsChan := make(chan pattern.Job[int], numJobs)
+	for i := 1; i <= numJobs; i++ {
+		jobsChan <- pattern.Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	for range NewRateLimited(ctx, limiter, jobsChan, throttled) {
+	}
+	assert.Less(t, limiter.Rate(), rate.Limit(1000))
+	assert.GreaterOrEqual(t, limiter.Rate(), rate.Limit(10))
+}
@@ -0,0 +1,200 @@
+// Package dynamic implements the dynamic rate-limited worker pool pattern.
+package dynamic
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+// RateLimitedPool is a rate-limited worker pool that starts a worker for every job, as fast as the limiter allows.
+//
+// Shutdown contract: whichever way the pool stops, it stops receiving jobs, its results channel is closed
+// once every in-flight job is done, and no goroutine is left behind.
+//   - Closing the jobs channel or calling Drain stops receiving jobs and lets in-flight jobs finish,
+//     their results are delivered, so the consumer must keep reading until the results channel is closed.
+//   - Calling Stop cancels the context of in-flight jobs, which report their outcome, typically a
+//     context.Canceled error. Jobs still waiting for the limiter report context.Canceled without being processed.
+//     The consumer must keep reading until the results channel is closed.
+//   - Cancelling the pool context stops the pool like Stop, but results are no longer guaranteed to be
+//     delivered: a consumer may stop reading as soon as it cancels the context.
+type RateLimitedPool[T any, U any] struct {
+	ctx       context.Context // ctx is the pool context, once done results are dropped rather than delivered.
+	runCtx    context.Context // runCtx is passed to in-flight jobs, it is also cancelled by Stop.
+	cancelRun context.CancelFunc
+	limiter   Limiter
+	keyed     *KeyedLimiter[T] // keyed is only set for pools created with NewKeyedRateLimitedPool.
+	jobs      <-chan pattern.Job[T]
+	results   chan pattern.Result[T, U]
+	process   *pattern.Processor[T, U]
+
+	wg           sync.WaitGroup
+	stopping     chan struct{} // stopping is closed by Drain and Stop to stop receiving jobs.
+	stoppingOnce sync.Once
+}
+
+// NewRateLimitedPool creates a rate-limited worker pool.
+// If the limiter is an Observer, such as an AdaptiveLimiter, it is told the latency and error of every processed job.
+// The options, such as pattern.WithRetry and pattern.WithDeadLetter, are applied around every call to processFunc.
+// Every retry waits for the limiter, like the first attempt of a job.
+func NewRateLimitedPool[T any, U any](ctx context.Context, limiter Limiter, jobs <-chan pattern.Job[T], processFunc pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) *RateLimitedPool[T, U] {
+	return newRateLimitedPool(ctx, limiter, nil, jobs, processFunc, opts...)
+}
+
+func newRateLimitedPool[T any, U any](ctx context.Context, limiter Limiter, keyed *KeyedLimiter[T], jobs <-chan pattern.Job[T], processFunc pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) *RateLimitedPool[T, U] {
+	if observer, ok := limiter.(Observer); ok {
+		processFunc = observed(observer, processFunc)
+	}
+	// Retries wait for the limiters like first attempts, so that they do not add to an overloaded upstream.
+	opts = append(slices.Clip(opts), pattern.WithRetryGate(func(ctx context.Context, job pattern.Job[T]) error {
+		if keyed != nil {
+			if err := keyed.waitToken(ctx, job); err != nil {
+				return err
+			}
+		}
+		return limiter.Wait(ctx)
+	}))
+
+	runCtx, cancelRun := context.WithCancel(ctx)
+	p := &RateLimitedPool[T, U]{
+		ctx:       ctx,
+		runCtx:    runCtx,
+		cancelRun: cancelRun,
+		limiter:   limiter,
+		keyed:     keyed,
+		jobs:      jobs,
+		results:   make(chan pattern.Result[T, U], limiter.Burst()),
+		process:   pattern.NewProcessor(processFunc, opts...),
+		stopping:  make(chan struct{}),
+	}
+
+	go func() {
+		defer func() {
+			// Close the results channel once all workers are done.
+			p.wg.Wait()
+			p.cancelRun()
+			close(p.results)
+		}()
+		p.dispatch()
+	}()
+
+	return p
+}
+
+// NewKeyedRateLimitedPool creates a rate-limited worker pool where every job must also be allowed by the
+// KeyedLimiter for its key. The jobs of a throttled key wait in their own goroutine, without holding up
+// the jobs of other keys, and then for the shared limiter. Once a key has KeyQuota.MaxPending jobs waiting,
+// its new jobs fail with ErrKeyBacklogFull until one of them starts, so a hot key pushes back on its
+// submitter instead of growing the number of goroutines, while the pool keeps receiving the jobs of other keys.
+func NewKeyedRateLimitedPool[T any, U any](ctx context.Context, limiter Limiter, keyed *KeyedLimiter[T], jobs <-chan pattern.Job[T], processFunc pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) *RateLimitedPool[T, U] {
+	return newRateLimitedPool(ctx, limiter, keyed, jobs, processFunc, opts...)
+}
+
+// NewRateLimited creates a rate-limited worker pool and returns its results, see RateLimitedPool.
+func NewRateLimited[T any, U any](ctx context.Context, limiter Limiter, jobs <-chan pattern.Job[T], processFunc pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) <-chan pattern.Result[T, U] {
+	return NewRateLimitedPool(ctx, limiter, jobs, processFunc, opts...).Results()
+}
+
+// Results returns the channel the results are delivered on.
+func (p *RateLimitedPool[T, U]) Results() <-chan pattern.Result[T, U] {
+	return p.results
+}
+
+// Drain stops receiving jobs and lets in-flight jobs finish. It does not wait for them.
+func (p *RateLimitedPool[T, U]) Drain() {
+	p.stoppingOnce.Do(func() { close(p.stopping) })
+}
+
+// Stop stops receiving jobs and cancels in-flight jobs. It does not wait for them.
+func (p *RateLimitedPool[T, U]) Stop() {
+	p.Drain()
+	p.cancelRun()
+}
+
+// dispatch receives jobs and starts a worker for each, as fast as the limiter allows.
+// A job the limiter cannot let start before the deadline of the pool context fails on its own.
+func (p *RateLimitedPool[T, U]) dispatch() {
+	for {
+		// Check stopping first, a select would pick a ready job half of the time after Drain.
+		select {
+		case <-p.stopping:
+			return
+		default:
+		}
+
+		select {
+		case <-p.ctx.Done():
+			slog.Info("shutting down goroutine", "reason", p.ctx.Err())
+			return
+		case <-p.stopping:
+			return // pool drained or stopped, exit dispatcher
+		case job, ok := <-p.jobs:
+			if !ok {
+				return // jobs channel closed, exit dispatcher
+			}
+			// Keyed pools wait for the limiters in the worker, so that a throttled key does not block the others,
+			// and reject the job if its key has no room for another waiting job.
+			var wait func(context.Context) (func(), error)
+			if p.keyed == nil {
+				if err := p.limiter.Wait(p.runCtx); err != nil {
+					p.send(pattern.Result[T, U]{Job: job, Err: err})
+					continue // the pool stops on the next iteration if it is stopping
+				}
+			} else {
+				var err error
+				if wait, err = p.keyed.tryReserve(job); err != nil {
+					p.send(pattern.Result[T, U]{Job: job, Err: err})
+					continue
+				}
+			}
+			p.wg.Add(1)
+			go func(job pattern.Job[T]) {
+				defer p.wg.Done()
+				p.work(job, wait)
+			}(job)
+		}
+	}
+}
+
+// work processes a single job and sends its result, waiting for the limiters first in keyed pools.
+func (p *RateLimitedPool[T, U]) work(job pattern.Job[T], wait func(context.Context) (func(), error)) {
+	if wait != nil {
+		release, err := wait(p.runCtx)
+		if err != nil {
+			p.send(pattern.Result[T, U]{Job: job, Err: err})
+			return
+		}
+		defer release()
+
+		if err := p.limiter.Wait(p.runCtx); err != nil {
+			p.send(pattern.Result[T, U]{Job: job, Err: err})
+			return
+		}
+	}
+
+	if result, ok := p.process.Process(p.runCtx, job); ok {
+		p.send(result)
+	}
+}
+
+// send delivers a result, or drops it once the pool context is done so that no goroutine blocks forever.
+func (p *RateLimitedPool[T, U]) send(result pattern.Result[T, U]) {
+	select {
+	case p.results <- result:
+	case <-p.ctx.Done():
+	}
+}
+
+// observed wraps processFunc to report the outcome of every call to the observer.
+func observed[T any, U any](observer Observer, processFunc pattern.ProcessFunc[T, U]) pattern.ProcessFunc[T, U] {
+	return func(ctx context.Context, value T) (U, error) {
+		start := time.Now()
+		result, err := processFunc(ctx, value)
+		observer.Observe(time.Since(start), err)
+		return result, err
+	}
+}
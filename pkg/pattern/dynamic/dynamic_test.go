@@ -0,0 +1,316 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/internal/leaktest"
+)
+
+func TestNewRateLimited(t *testing.T) {
+	type args[T any, U any] struct {
+		limiter     *rate.Limiter
+		jobs        []pattern.Job[T]
+		processFunc pattern.ProcessFunc[T, U]
+	}
+	type testCase[T any, U any] struct {
+		name   string
+		args   args[T, U]
+		cancel bool // whether to cancel the context before waiting for the result
+		want   []pattern.Result[T, U]
+	}
+
+	tests := []testCase[int, int]{
+		{
+			name: "Positive Values",
+			args: args[int, int]{
+				limiter: rate.NewLimiter(rate.Every(time.Millisecond*100), 10),
+				jobs: func() []pattern.Job[int] {
+					var jobs []pattern.Job[int]
+					for i := 1; i <= 10; i++ {
+						jobs = append(jobs, pattern.Job[int]{ID: i, Value: i})
+					}
+					return jobs
+				}(),
+				processFunc: squareNonNegative,
+			},
+			want: func() []pattern.Result[int, int] {
+				var results []pattern.Result[int, int]
+				for i := 1; i <= 10; i++ {
+					results = append(results, pattern.Result[int, int]{Job: pattern.Job[int]{ID: i, Value: i}, Value: i * i, Attempts: 1})
+				}
+				return results
+			}(),
+		},
+		{
+			name: "Negative Value",
+			args: args[int, int]{
+				limiter: rate.NewLimiter(rate.Every(time.Millisecond*100), 10),
+				jobs: []pattern.Job[int]{
+					{ID: 1, Value: -1},
+				},
+				processFunc: squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: -1}, Err: ErrNegativeValue, Attempts: 1},
+			},
+		},
+		{
+			name: "Cancelled context",
+			args: args[int, int]{
+				limiter: rate.NewLimiter(rate.Every(time.Millisecond*100), 10),
+				jobs: []pattern.Job[int]{
+					{ID: 1, Value: -1},
+				},
+				processFunc: squareNonNegative,
+			},
+			cancel: true,
+			want:   []pattern.Result[int, int]{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			jobsChan := make(chan pattern.Job[int], len(tt.args.jobs))
+			resultsChan := NewRateLimited(ctx, tt.args.limiter, jobsChan, tt.args.processFunc)
+			if tt.cancel {
+				cancel()                           // cancel the context before waiting for the result
+				time.Sleep(100 * time.Millisecond) // propagation of the context takes time
+			}
+
+			for _, job := range tt.args.jobs {
+				jobsChan <- job
+			}
+			close(jobsChan)
+
+			var gotResults []pattern.Result[int, int]
+			for result := range resultsChan {
+				gotResults = append(gotResults, result)
+			}
+			assert.ElementsMatch(t, tt.want, gotResults)
+		})
+	}
+}
+
+var ErrNegativeValue = errors.New("negative value")
+
+// Example squareNonNegative function that squares non-negative integer.
+func squareNonNegative(ctx context.Context, value int) (int, error) {
+	if value < 0 {
+		return 0, ErrNegativeValue
+	}
+	return value * value, nil
+}
+
+// blockingFunc blocks until its context is done, or returns the square of value once release is closed.
+func blockingFunc(started chan<- int, release <-chan struct{}) pattern.ProcessFunc[int, int] {
+	return func(ctx context.Context, value int) (int, error) {
+		started <- value
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-release:
+			return value * value, nil
+		}
+	}
+}
+
+func TestRateLimitedPool_Shutdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		shutdown func(*RateLimitedPool[int, int], context.CancelFunc, chan struct{})
+		read     bool // whether to read results until the channel is closed
+		want     []pattern.Result[int, int]
+	}{
+		{
+			name: "Drain finishes in-flight jobs",
+			shutdown: func(p *RateLimitedPool[int, int], _ context.CancelFunc, release chan struct{}) {
+				p.Drain()
+				close(release)
+			},
+			read: true,
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: 1}, Value: 1, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 2, Value: 2}, Value: 4, Attempts: 1},
+			},
+		},
+		{
+			name: "Stop cancels in-flight jobs",
+			shutdown: func(p *RateLimitedPool[int, int], _ context.CancelFunc, _ chan struct{}) {
+				p.Stop()
+			},
+			read: true,
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: 1}, Err: context.Canceled, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 2, Value: 2}, Err: context.Canceled, Attempts: 1},
+			},
+		},
+		{
+			name: "Cancelled context with a consumer that stopped reading",
+			shutdown: func(_ *RateLimitedPool[int, int], cancel context.CancelFunc, _ chan struct{}) {
+				cancel()
+			},
+		},
+		{
+			name: "Stop with a consumer that stopped reading and cancelled",
+			shutdown: func(p *RateLimitedPool[int, int], cancel context.CancelFunc, _ chan struct{}) {
+				p.Stop()
+				cancel()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer leaktest.Verify(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			started := make(chan int, 2)
+			release := make(chan struct{})
+			jobsChan := make(chan pattern.Job[int]) // never closed, the pool must stop on its own.
+			pool := NewRateLimitedPool(ctx, rate.NewLimiter(rate.Inf, 1), jobsChan, blockingFunc(started, release))
+
+			jobsChan <- pattern.Job[int]{ID: 1, Value: 1}
+			jobsChan <- pattern.Job[int]{ID: 2, Value: 2}
+			<-started
+			<-started
+
+			tt.shutdown(pool, cancel, release)
+			if !tt.read {
+				return
+			}
+
+			var gotResults []pattern.Result[int, int]
+			for result := range pool.Results() {
+				gotResults = append(gotResults, result)
+			}
+			assert.ElementsMatch(t, tt.want, gotResults)
+		})
+	}
+}
+
+func TestRateLimitedPool_StopWhileWaitingForLimiter(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	// The first job takes the only token, the second one waits for an hour.
+	jobsChan := make(chan pattern.Job[int], 2)
+	pool := NewRateLimitedPool(ctx, rate.NewLimiter(rate.Every(time.Hour), 1), jobsChan, squareNonNegative)
+	jobsChan <- pattern.Job[int]{ID: 1, Value: 1}
+	jobsChan <- pattern.Job[int]{ID: 2, Value: 2}
+
+	first := <-pool.Results()
+	assert.Equal(t, pattern.Result[int, int]{Job: pattern.Job[int]{ID: 1, Value: 1}, Value: 1, Attempts: 1}, first)
+
+	pool.Stop()
+	var gotResults []pattern.Result[int, int]
+	for result := range pool.Results() {
+		gotResults = append(gotResults, result)
+	}
+	assert.Equal(t, []pattern.Result[int, int]{{Job: pattern.Job[int]{ID: 2, Value: 2}, Err: context.Canceled}}, gotResults)
+}
+
+// countingLimiter counts the tokens taken from an unlimited limiter.
+type countingLimiter struct {
+	waits atomic.Int64
+}
+
+func (l *countingLimiter) Wait(context.Context) error {
+	l.waits.Add(1)
+	return nil
+}
+
+func (l *countingLimiter) Burst() int {
+	return 1
+}
+
+func TestRateLimitedPool_RetriesWaitForLimiter(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	calls := 0
+	failTwice := func(_ context.Context, value int) (int, error) {
+		if calls++; calls <= 2 {
+			return 0, ErrNegativeValue
+		}
+		return value * value, nil
+	}
+
+	limiter := &countingLimiter{}
+	jobsChan := make(chan pattern.Job[int], 1)
+	jobsChan <- pattern.Job[int]{ID: 1, Value: 3}
+	close(jobsChan)
+	retry := pattern.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	results := NewRateLimited(context.Background(), limiter, jobsChan, failTwice, pattern.WithRetry[int](retry))
+
+	assert.Equal(t, pattern.Result[int, int]{Job: pattern.Job[int]{ID: 1, Value: 3}, Value: 9, Attempts: 3}, <-results)
+	_, ok := <-results
+	assert.False(t, ok)
+	assert.Equal(t, int64(3), limiter.waits.Load(), "every attempt should take a token")
+}
+
+func TestRateLimitedPool_LimiterPastDeadlineFailsOnlyThatJob(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The first job takes the only token, the limiter cannot let the second one start before the deadline.
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	jobsChan := make(chan pattern.Job[int], 3)
+	pool := NewRateLimitedPool(ctx, limiter, jobsChan, squareNonNegative)
+	jobsChan <- pattern.Job[int]{ID: 1, Value: 1}
+	jobsChan <- pattern.Job[int]{ID: 2, Value: 2}
+	for i := 0; i < 2; i++ {
+		result := <-pool.Results()
+		if result.Job.ID == 1 {
+			assert.Equal(t, pattern.Result[int, int]{Job: pattern.Job[int]{ID: 1, Value: 1}, Value: 1, Attempts: 1}, result)
+			continue
+		}
+		assert.Equal(t, pattern.Job[int]{ID: 2, Value: 2}, result.Job)
+		assert.Error(t, result.Err)
+	}
+
+	// The dispatcher is still running.
+	limiter.SetLimit(rate.Inf)
+	jobsChan <- pattern.Job[int]{ID: 3, Value: 3}
+	close(jobsChan)
+	assert.Equal(t, pattern.Result[int, int]{Job: pattern.Job[int]{ID: 3, Value: 3}, Value: 9, Attempts: 1}, <-pool.Results())
+	_, ok := <-pool.Results()
+	assert.False(t, ok)
+}
+
+func TestRateLimitedPool_DrainIgnoresReadyJobs(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	for i := 0; i < 20; i++ {
+		jobsChan := make(chan pattern.Job[int], 3)
+		for id := 1; id <= 3; id++ {
+			jobsChan <- pattern.Job[int]{ID: id, Value: id}
+		}
+		// The first job takes the only token, the dispatcher waits for the limiter with the second one.
+		pool := NewRateLimitedPool(context.Background(), rate.NewLimiter(rate.Every(20*time.Millisecond), 1), jobsChan, squareNonNegative)
+		<-pool.Results()
+
+		// Once drained, the third job, ready on the jobs channel, must not be started.
+		pool.Drain()
+		var ids []int
+		for result := range pool.Results() {
+			ids = append(ids, result.Job.ID)
+		}
+		assert.Equal(t, []int{2}, ids)
+	}
+}
@@ -0,0 +1,225 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+// ErrKeyBacklogFull is the error of a job rejected by a keyed pool because its key already has
+// KeyQuota.MaxPending jobs waiting for its quota.
+var ErrKeyBacklogFull = errors.New("too many jobs waiting for the quota of the key")
+
+// defaultMaxPending is the number of jobs a key may have waiting for its quota when its quota sets none.
+const defaultMaxPending = 1024
+
+// KeyQuota is the quota of a single key.
+type KeyQuota struct {
+	// Limit is the rate at which jobs of the key may start, zero means no limit.
+	Limit rate.Limit
+	// Burst is the token bucket size, minimum one.
+	Burst int
+	// MaxInFlight caps the number of jobs of the key processed at once, zero means no cap.
+	MaxInFlight int
+	// MaxPending caps the number of jobs of the key waiting for its quota, 1024 by default. Once a key has that
+	// many jobs waiting, Acquire blocks and a keyed pool fails the key's new jobs with ErrKeyBacklogFull, which
+	// pushes back on the submitter of that key rather than piling up goroutines, or holding up the other keys.
+	MaxPending int
+}
+
+// KeyedConfig configures a KeyedLimiter.
+type KeyedConfig[T any] struct {
+	// Key extracts the key of a job, such as its upstream host or tenant.
+	Key func(pattern.Job[T]) string
+	// Default is the quota of keys without a specific one.
+	Default KeyQuota
+	// Quota, if set, returns the specific quota of a key, or false to use the default one.
+	Quota func(key string) (KeyQuota, bool)
+	// IdleTimeout evicts keys without jobs for that long, zero means keys are never evicted.
+	// It should be longer than the time it takes a key's token bucket to refill.
+	IdleTimeout time.Duration
+}
+
+// KeyedLimiter gives every key its own token bucket and in-flight cap, so that jobs of a throttled key
+// do not hold up jobs of other keys.
+type KeyedLimiter[T any] struct {
+	config KeyedConfig[T]
+
+	mu        sync.Mutex
+	keys      map[string]*keyState
+	lastSweep time.Time
+}
+
+// keyState holds the limiter of a single key.
+type keyState struct {
+	limiter  *rate.Limiter
+	inFlight chan struct{} // inFlight is a semaphore, nil when the key has no in-flight cap.
+	pending  chan struct{} // pending is a semaphore bounding the jobs waiting for the quota of the key.
+	refs     int           // refs counts the jobs of the key waiting or in flight.
+	lastUsed time.Time
+}
+
+// NewKeyedLimiter creates a KeyedLimiter.
+func NewKeyedLimiter[T any](config KeyedConfig[T]) *KeyedLimiter[T] {
+	return &KeyedLimiter[T]{
+		config:    config,
+		keys:      make(map[string]*keyState),
+		lastSweep: time.Now(),
+	}
+}
+
+// Len returns the number of keys currently tracked.
+func (k *KeyedLimiter[T]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.keys)
+}
+
+// Acquire waits until the job's key allows it to start, or the context is done.
+// On success, release must be called once the job is done.
+func (k *KeyedLimiter[T]) Acquire(ctx context.Context, job pattern.Job[T]) (release func(), err error) {
+	wait, err := k.reserve(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	return wait(ctx)
+}
+
+// reserve waits until the job's key has room for one more waiting job, or the context is done.
+// On success, wait must be called to wait for the quota of the key.
+func (k *KeyedLimiter[T]) reserve(ctx context.Context, job pattern.Job[T]) (wait func(context.Context) (release func(), err error), err error) {
+	state := k.acquireState(k.config.Key(job))
+
+	select {
+	case <-ctx.Done():
+		k.releaseState(state)
+		return nil, ctx.Err()
+	case state.pending <- struct{}{}:
+	}
+	return k.waitFunc(state), nil
+}
+
+// tryReserve is like reserve but returns ErrKeyBacklogFull rather than waiting for room.
+func (k *KeyedLimiter[T]) tryReserve(job pattern.Job[T]) (wait func(context.Context) (release func(), err error), err error) {
+	state := k.acquireState(k.config.Key(job))
+
+	select {
+	case state.pending <- struct{}{}:
+	default:
+		k.releaseState(state)
+		return nil, ErrKeyBacklogFull
+	}
+	return k.waitFunc(state), nil
+}
+
+// waitFunc returns the function waiting for the quota of a key once the job holds a pending slot.
+func (k *KeyedLimiter[T]) waitFunc(state *keyState) func(context.Context) (func(), error) {
+	return func(ctx context.Context) (func(), error) {
+		defer func() { <-state.pending }() // The job no longer waits, whether it starts or gives up.
+		return k.wait(ctx, state)
+	}
+}
+
+// wait waits for an in-flight slot and a token of the key, or the context is done.
+func (k *KeyedLimiter[T]) wait(ctx context.Context, state *keyState) (release func(), err error) {
+	if state.inFlight != nil {
+		select {
+		case <-ctx.Done():
+			k.releaseState(state)
+			return nil, ctx.Err()
+		case state.inFlight <- struct{}{}:
+		}
+	}
+
+	if err := state.limiter.Wait(ctx); err != nil {
+		if state.inFlight != nil {
+			<-state.inFlight
+		}
+		k.releaseState(state)
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if state.inFlight != nil {
+				<-state.inFlight
+			}
+			k.releaseState(state)
+		})
+	}, nil
+}
+
+// waitToken waits for a token of the job's key, without taking an in-flight slot, e.g. to retry a job that holds one.
+func (k *KeyedLimiter[T]) waitToken(ctx context.Context, job pattern.Job[T]) error {
+	state := k.acquireState(k.config.Key(job))
+	defer k.releaseState(state)
+	return state.limiter.Wait(ctx)
+}
+
+// acquireState returns the state of a key, creating it if needed, and evicts idle keys.
+func (k *KeyedLimiter[T]) acquireState(key string) *keyState {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if k.config.IdleTimeout > 0 && now.Sub(k.lastSweep) >= k.config.IdleTimeout/2 {
+		k.lastSweep = now
+		for other, state := range k.keys {
+			if state.refs == 0 && now.Sub(state.lastUsed) >= k.config.IdleTimeout {
+				delete(k.keys, other)
+			}
+		}
+	}
+
+	state, ok := k.keys[key]
+	if !ok {
+		state = newKeyState(k.quota(key))
+		k.keys[key] = state
+	}
+	state.refs++
+	state.lastUsed = now
+	return state
+}
+
+// releaseState marks a job of the key as done.
+func (k *KeyedLimiter[T]) releaseState(state *keyState) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state.refs--
+	state.lastUsed = time.Now()
+}
+
+// quota returns the quota of a key.
+func (k *KeyedLimiter[T]) quota(key string) KeyQuota {
+	if k.config.Quota != nil {
+		if quota, ok := k.config.Quota(key); ok {
+			return quota
+		}
+	}
+	return k.config.Default
+}
+
+func newKeyState(quota KeyQuota) *keyState {
+	limit := quota.Limit
+	if limit <= 0 {
+		limit = rate.Inf
+	}
+
+	pending := quota.MaxPending
+	if pending <= 0 {
+		pending = defaultMaxPending
+	}
+
+	state := &keyState{limiter: rate.NewLimiter(limit, max(quota.Burst, 1)), pending: make(chan struct{}, pending)}
+	if quota.MaxInFlight > 0 {
+		state.inFlight = make(chan struct{}, quota.MaxInFlight)
+	}
+	return state
+}
@@ -0,0 +1,218 @@
+package dynamic
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/internal/leaktest"
+)
+
+// parityKey keys jobs by the parity of their value.
+func parityKey(job pattern.Job[int]) string {
+	if job.Value%2 == 0 {
+		return "even"
+	}
+	return "odd"
+}
+
+func TestKeyedRateLimitedPool_ThrottledKeyDoesNotBlockOthers(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	keyed := NewKeyedLimiter(KeyedConfig[int]{
+		Key: parityKey,
+		Quota: func(key string) (KeyQuota, bool) {
+			// odd values get a single token that never refills within the test
+			return KeyQuota{Limit: rate.Every(time.Hour), Burst: 1}, key == "odd"
+		},
+	})
+
+	jobsChan := make(chan pattern.Job[int])
+	pool := NewKeyedRateLimitedPool(context.Background(), rate.NewLimiter(rate.Inf, 1), keyed, jobsChan, squareNonNegative)
+
+	go func() {
+		defer close(jobsChan)
+		for i := 1; i <= 6; i++ {
+			jobsChan <- pattern.Job[int]{ID: i, Value: i}
+		}
+	}()
+
+	// all even jobs and whichever odd job took the single token complete, the other odd jobs stay throttled
+	keys := map[string]int{}
+	for i := 0; i < 4; i++ {
+		result := <-pool.Results()
+		assert.NoError(t, result.Err)
+		keys[parityKey(result.Job)]++
+	}
+	assert.Equal(t, map[string]int{"even": 3, "odd": 1}, keys)
+
+	pool.Stop()
+	cancelled := 0
+	for result := range pool.Results() {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+		assert.Equal(t, "odd", parityKey(result.Job))
+		cancelled++
+	}
+	assert.Equal(t, 2, cancelled)
+}
+
+func TestKeyedRateLimitedPool_DefaultQuotaDoesNotBlockOthers(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	keyed := NewKeyedLimiter(KeyedConfig[int]{
+		Key: parityKey,
+		Quota: func(key string) (KeyQuota, bool) {
+			// odd values get a single token that never refills within the test, and the default MaxPending
+			return KeyQuota{Limit: rate.Every(time.Hour), Burst: 1}, key == "odd"
+		},
+	})
+
+	jobsChan := make(chan pattern.Job[int])
+	pool := NewKeyedRateLimitedPool(context.Background(), rate.NewLimiter(rate.Inf, 1), keyed, jobsChan, squareNonNegative)
+
+	// the first odd job takes the token
+	jobsChan <- pattern.Job[int]{ID: 1, Value: 1}
+	assert.NoError(t, (<-pool.Results()).Err)
+
+	const rejected = 2
+	go func() {
+		defer close(jobsChan)
+		for i := 0; i < defaultMaxPending+rejected; i++ {
+			jobsChan <- pattern.Job[int]{ID: 3 + 2*i, Value: 3 + 2*i}
+		}
+		jobsChan <- pattern.Job[int]{ID: 2, Value: 2}
+		jobsChan <- pattern.Job[int]{ID: 4, Value: 4}
+	}()
+
+	// the next odd jobs wait for the key until it has no room left, then they are rejected,
+	// and the even jobs go through regardless
+	errs := map[error]int{}
+	for i := 0; i < rejected+2; i++ {
+		select {
+		case result := <-pool.Results():
+			errs[result.Err]++
+		case <-time.After(time.Second):
+			t.Fatalf("a throttled key should not block the others, got %v", errs)
+		}
+	}
+	assert.Equal(t, map[error]int{nil: 2, ErrKeyBacklogFull: rejected}, errs)
+
+	pool.Stop()
+	cancelled := 0
+	for result := range pool.Results() {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+		cancelled++
+	}
+	assert.Equal(t, defaultMaxPending, cancelled)
+}
+
+func TestKeyedRateLimitedPool_MaxPendingRejects(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	keyed := NewKeyedLimiter(KeyedConfig[int]{
+		Key:     parityKey,
+		Default: KeyQuota{Limit: rate.Every(time.Hour), Burst: 1, MaxPending: 2},
+	})
+
+	jobsChan := make(chan pattern.Job[int])
+	pool := NewKeyedRateLimitedPool(context.Background(), rate.NewLimiter(rate.Inf, 1), keyed, jobsChan, squareNonNegative)
+
+	// all jobs share the "even" key, the first one takes the single token
+	jobsChan <- pattern.Job[int]{ID: 1, Value: 2}
+	assert.NoError(t, (<-pool.Results()).Err)
+
+	go func() {
+		defer close(jobsChan)
+		for i := 2; i <= 5; i++ {
+			jobsChan <- pattern.Job[int]{ID: i, Value: 2 * i}
+		}
+	}()
+
+	// two jobs wait for the key, and the last two are rejected without holding up the submitter
+	for i := 4; i <= 5; i++ {
+		result := <-pool.Results()
+		assert.ErrorIs(t, result.Err, ErrKeyBacklogFull)
+		assert.Equal(t, i, result.Job.ID)
+	}
+
+	pool.Stop()
+	cancelled := 0
+	for result := range pool.Results() {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+		cancelled++
+	}
+	assert.Equal(t, 2, cancelled)
+}
+
+func TestKeyedRateLimitedPool_MaxInFlight(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	const maxInFlight = 2
+	keyed := NewKeyedLimiter(KeyedConfig[int]{
+		Key:     parityKey,
+		Default: KeyQuota{MaxInFlight: maxInFlight},
+	})
+
+	var inFlight, peak atomic.Int64
+	processFunc := func(ctx context.Context, value int) (int, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			if old := peak.Load(); current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return value, nil
+	}
+
+	jobsChan := make(chan pattern.Job[int], 10)
+	for i := 0; i < 10; i++ {
+		jobsChan <- pattern.Job[int]{ID: i, Value: 2 * i} // all jobs share the "even" key
+	}
+	close(jobsChan)
+
+	pool := NewKeyedRateLimitedPool(context.Background(), rate.NewLimiter(rate.Inf, 1), keyed, jobsChan, processFunc)
+	count := 0
+	for result := range pool.Results() {
+		assert.NoError(t, result.Err)
+		count++
+	}
+	assert.Equal(t, 10, count)
+	assert.Equal(t, int64(maxInFlight), peak.Load())
+}
+
+func TestKeyedLimiter_EvictsIdleKeys(t *testing.T) {
+	keyed := NewKeyedLimiter(KeyedConfig[int]{
+		Key:         func(job pattern.Job[int]) string { return strconv.Itoa(job.ID) },
+		IdleTimeout: 20 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	idle, err := keyed.Acquire(ctx, pattern.Job[int]{ID: 1})
+	assert.NoError(t, err)
+	idle()
+	busy, err := keyed.Acquire(ctx, pattern.Job[int]{ID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, keyed.Len())
+
+	time.Sleep(30 * time.Millisecond)
+	release, err := keyed.Acquire(ctx, pattern.Job[int]{ID: 3})
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, 2, keyed.Len(), "idle key 1 should be evicted, key 2 is still in flight")
+
+	busy()
+	busy() // release is idempotent
+	time.Sleep(30 * time.Millisecond)
+	release, err = keyed.Acquire(ctx, pattern.Job[int]{ID: 3})
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, 1, keyed.Len())
+}
@@ -0,0 +1,129 @@
+// Package fanoutin implements the fan-out, fan-in pattern.
+package fanoutin
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+// FanOut creates a pool of workers.
+// The options, such as pattern.WithRetry and pattern.WithDeadLetter, are applied around every call to processFunc.
+// With pattern.WithOrderedResults, results are emitted in the order of jobs and at most window workers run at once.
+func FanOut[T any, U any](ctx context.Context, jobs []pattern.Job[T], processFunc pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) chan pattern.Result[T, U] {
+	processor := pattern.NewProcessor(processFunc, opts...)
+	results := make(chan pattern.Result[T, U], len(jobs))
+	sequencer := processor.Sequencer(results)
+	var wg sync.WaitGroup
+
+	// Launch a new worker for each job.
+	go func() {
+		defer func() {
+			// Close the results channel once all workers are done.
+			wg.Wait()
+			close(results)
+		}()
+
+		for i, job := range jobs {
+			// In ordered mode, wait for room in the reorder window before launching the worker.
+			if sequencer != nil && !sequencer.Acquire(ctx) {
+				slog.Info("shutting down goroutine", "reason", ctx.Err(), "total jobs", len(jobs), "finished jobs", i)
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				slog.Info("shutting down goroutine", "reason", ctx.Err(), "total jobs", len(jobs), "finished jobs", i)
+				return
+			default:
+				wg.Add(1) // Increment the counter whenever a new job is received.
+				go func(seq int, job pattern.Job[T]) {
+					defer wg.Done() // Decrement the counter when the goroutine completes.
+
+					result, ok := processor.Process(ctx, job)
+					switch {
+					case sequencer != nil:
+						sequencer.Done(seq, result, ok)
+					case ok:
+						results <- result
+					}
+				}(i, job)
+			}
+		}
+
+	}()
+
+	return results
+}
+
+// BoundedFanOut creates a pool of at most limit workers processing a stream of jobs.
+// Unlike FanOut, memory stays bounded regardless of the number of jobs: a new worker is only launched
+// once one of the limit running workers has delivered its result.
+// The options are applied the same way as in FanOut.
+func BoundedFanOut[T any, U any](ctx context.Context, limit int, jobs <-chan pattern.Job[T], processFunc pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) <-chan pattern.Result[T, U] {
+	limit = max(limit, 1)
+	processor := pattern.NewProcessor(processFunc, opts...)
+	results := make(chan pattern.Result[T, U], limit)
+	sequencer := processor.Sequencer(results)
+	slots := make(chan struct{}, limit) // slots holds one token per running worker.
+	var wg sync.WaitGroup
+
+	shutdown := func(started int) {
+		slog.Info("shutting down goroutine", "reason", ctx.Err(), "started jobs", started)
+	}
+
+	// Launch a new worker for each job, as long as there is a free slot.
+	go func() {
+		defer func() {
+			// Close the results channel once all workers are done.
+			wg.Wait()
+			close(results)
+		}()
+
+		for i := 0; ; i++ {
+			// In ordered mode, wait for room in the reorder window before launching the worker.
+			if sequencer != nil && !sequencer.Acquire(ctx) {
+				shutdown(i)
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				shutdown(i)
+				return
+			case slots <- struct{}{}:
+			}
+
+			select {
+			case <-ctx.Done():
+				shutdown(i)
+				return
+			case job, ok := <-jobs:
+				if !ok {
+					return // jobs channel closed, wait for the running workers
+				}
+				if ctx.Err() != nil {
+					shutdown(i) // both channels were ready, prefer shutting down.
+					return
+				}
+				wg.Add(1) // Increment the counter whenever a new job is received.
+				go func(seq int, job pattern.Job[T]) {
+					defer wg.Done() // Decrement the counter when the goroutine completes.
+					defer func() { <-slots }()
+
+					result, ok := processor.Process(ctx, job)
+					switch {
+					case sequencer != nil:
+						sequencer.Done(seq, result, ok)
+					case ok:
+						results <- result
+					}
+				}(i, job)
+			}
+		}
+	}()
+
+	return results
+}
@@ -0,0 +1,82 @@
+package fanoutin
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+var jobCounts = []int{1_000, 10_000, 100_000, 1_000_000}
+
+// peakUsage samples the goroutines and allocated heap every sampleEvery calls to sample and keeps the highest values.
+type peakUsage struct {
+	sampleEvery int
+	calls       int
+	goroutines  int
+	heap        uint64
+}
+
+func newPeakUsage(numJobs int) *peakUsage {
+	runtime.GC() // start from a clean heap so garbage from earlier runs does not count.
+	return &peakUsage{sampleEvery: max(numJobs/100, 1)}
+}
+
+func (p *peakUsage) sample() {
+	p.calls++
+	if p.calls%p.sampleEvery != 0 {
+		return
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	p.heap = max(p.heap, stats.HeapAlloc)
+	p.goroutines = max(p.goroutines, runtime.NumGoroutine())
+}
+
+func (p *peakUsage) report(b *testing.B) {
+	b.ReportMetric(float64(p.heap)/(1<<20), "peak-heap-MiB")
+	b.ReportMetric(float64(p.goroutines), "peak-goroutines")
+}
+
+func BenchmarkFanOut(b *testing.B) {
+	for _, numJobs := range jobCounts {
+		b.Run(fmt.Sprintf("jobs=%d", numJobs), func(b *testing.B) {
+			usage := newPeakUsage(numJobs)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				jobs := make([]pattern.Job[int], numJobs)
+				for j := range jobs {
+					jobs[j] = pattern.Job[int]{ID: j, Value: j}
+				}
+				for range FanOut(context.Background(), jobs, squareNonNegative) {
+					usage.sample()
+				}
+			}
+			usage.report(b)
+		})
+	}
+}
+
+func BenchmarkBoundedFanOut(b *testing.B) {
+	for _, numJobs := range jobCounts {
+		b.Run(fmt.Sprintf("jobs=%d", numJobs), func(b *testing.B) {
+			usage := newPeakUsage(numJobs)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				jobs := make(chan pattern.Job[int])
+				go func() {
+					defer close(jobs)
+					for j := 0; j < numJobs; j++ {
+						jobs <- pattern.Job[int]{ID: j, Value: j}
+					}
+				}()
+				for range BoundedFanOut(context.Background(), runtime.GOMAXPROCS(0), jobs, squareNonNegative) {
+					usage.sample()
+				}
+			}
+			usage.report(b)
+		})
+	}
+}
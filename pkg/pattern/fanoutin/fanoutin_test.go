@@ -0,0 +1,288 @@
+package fanoutin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+var ErrNegativeValue = errors.New("negative value")
+
+// Example squareNonNegative function that squares non-negative integer.
+func squareNonNegative(_ context.Context, value int) (int, error) {
+	if value < 0 {
+		return 0, ErrNegativeValue
+	}
+	return value * value, nil
+}
+
+func TestFanOut(t *testing.T) {
+	type args[T any, U any] struct {
+		jobs    []pattern.Job[T]
+		process pattern.ProcessFunc[T, U]
+	}
+	type testCase[T any, U any] struct {
+		name   string
+		args   args[T, U]
+		cancel bool // whether to cancel the context before waiting for the result
+		want   []pattern.Result[T, U]
+	}
+
+	tests := []testCase[int, int]{
+		{
+			name: "Positive Values",
+			args: args[int, int]{
+				jobs: func() []pattern.Job[int] {
+					var jobs []pattern.Job[int]
+					for i := 1; i <= 10; i++ {
+						jobs = append(jobs, pattern.Job[int]{ID: i, Value: i})
+					}
+					return jobs
+				}(),
+				process: squareNonNegative,
+			},
+			want: func() []pattern.Result[int, int] {
+				var results []pattern.Result[int, int]
+				for i := 1; i <= 10; i++ {
+					results = append(results, pattern.Result[int, int]{Job: pattern.Job[int]{ID: i, Value: i}, Value: i * i, Attempts: 1})
+				}
+				return results
+			}(),
+		},
+		{
+			name: "Negative Value",
+			args: args[int, int]{
+				jobs:    []pattern.Job[int]{{ID: 1, Value: -1}},
+				process: squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: -1}, Err: ErrNegativeValue, Attempts: 1},
+			},
+		},
+		{
+			name: "Cancelled context",
+			args: args[int, int]{
+				jobs:    []pattern.Job[int]{{ID: 1, Value: -1}},
+				process: squareNonNegative,
+			},
+			cancel: true,
+			want:   []pattern.Result[int, int]{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			if tt.cancel {
+				cancel() // cancel the context before waiting for the result
+			}
+
+			got := FanOut(ctx, tt.args.jobs, tt.args.process)
+			var gotResults []pattern.Result[int, int]
+			for result := range got {
+				gotResults = append(gotResults, result)
+			}
+			assert.ElementsMatch(t, tt.want, gotResults)
+		})
+	}
+}
+
+func TestFanOut_DeadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobs := []pattern.Job[int]{{ID: 1, Value: 2}, {ID: 2, Value: -3}, {ID: 3, Value: 4}}
+	deadLetters := make(chan pattern.DeadLetter[int], len(jobs))
+
+	var gotResults []pattern.Result[int, int]
+	for result := range FanOut(ctx, jobs, squareNonNegative, pattern.WithDeadLetter(deadLetters)) {
+		gotResults = append(gotResults, result)
+	}
+	close(deadLetters)
+
+	assert.ElementsMatch(t, []pattern.Result[int, int]{
+		{Job: pattern.Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+		{Job: pattern.Job[int]{ID: 3, Value: 4}, Value: 16, Attempts: 1},
+	}, gotResults)
+
+	var batch []pattern.DeadLetter[int]
+	for letter := range deadLetters {
+		batch = append(batch, letter)
+	}
+	if assert.Len(t, batch, 1) {
+		assert.Equal(t, pattern.Job[int]{ID: 2, Value: -3}, batch[0].Job)
+		assert.ErrorIs(t, batch[0].Err, ErrNegativeValue)
+		assert.Equal(t, 1, batch[0].Attempts)
+	}
+
+	// Replay the dead letters once the cause of the failure is fixed.
+	abs := func(ctx context.Context, value int) (int, error) {
+		return squareNonNegative(ctx, max(value, -value))
+	}
+	var replayed []pattern.Result[int, int]
+	for result := range FanOut(ctx, pattern.ReplayJobs(batch), abs) {
+		replayed = append(replayed, result)
+	}
+	assert.Equal(t, []pattern.Result[int, int]{{Job: pattern.Job[int]{ID: 2, Value: -3}, Value: 9, Attempts: 1}}, replayed)
+}
+
+func TestFanOut_OrderedResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	numJobs := 50
+	var jobs []pattern.Job[int]
+	var want []pattern.Result[int, int]
+	for i := 1; i <= numJobs; i++ {
+		jobs = append(jobs, pattern.Job[int]{ID: i, Value: i})
+		want = append(want, pattern.Result[int, int]{Job: pattern.Job[int]{ID: i, Value: i}, Value: i * i, Attempts: 1})
+	}
+
+	// Earlier jobs take longer, so completion order is the reverse of submission order within the window.
+	slowStart := func(ctx context.Context, value int) (int, error) {
+		time.Sleep(time.Duration(numJobs-value) * 100 * time.Microsecond)
+		return squareNonNegative(ctx, value)
+	}
+
+	var gotResults []pattern.Result[int, int]
+	for result := range FanOut(ctx, jobs, slowStart, pattern.WithOrderedResults[int](8)) {
+		gotResults = append(gotResults, result)
+	}
+	assert.Equal(t, want, gotResults)
+}
+
+func TestBoundedFanOut(t *testing.T) {
+	type args[T any, U any] struct {
+		limit   int
+		jobs    []pattern.Job[T]
+		process pattern.ProcessFunc[T, U]
+	}
+	type testCase[T any, U any] struct {
+		name   string
+		args   args[T, U]
+		cancel bool // whether to cancel the context before waiting for the result
+		want   []pattern.Result[T, U]
+	}
+
+	tests := []testCase[int, int]{
+		{
+			name: "Positive Values",
+			args: args[int, int]{
+				limit: 3,
+				jobs: func() []pattern.Job[int] {
+					var jobs []pattern.Job[int]
+					for i := 1; i <= 10; i++ {
+						jobs = append(jobs, pattern.Job[int]{ID: i, Value: i})
+					}
+					return jobs
+				}(),
+				process: squareNonNegative,
+			},
+			want: func() []pattern.Result[int, int] {
+				var results []pattern.Result[int, int]
+				for i := 1; i <= 10; i++ {
+					results = append(results, pattern.Result[int, int]{Job: pattern.Job[int]{ID: i, Value: i}, Value: i * i, Attempts: 1})
+				}
+				return results
+			}(),
+		},
+		{
+			name: "Negative Value",
+			args: args[int, int]{
+				limit:   1,
+				jobs:    []pattern.Job[int]{{ID: 1, Value: -1}},
+				process: squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: -1}, Err: ErrNegativeValue, Attempts: 1},
+			},
+		},
+		{
+			name: "Zero limit",
+			args: args[int, int]{
+				limit:   0,
+				jobs:    []pattern.Job[int]{{ID: 1, Value: 2}},
+				process: squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+			},
+		},
+		{
+			name: "Cancelled context",
+			args: args[int, int]{
+				limit:   2,
+				jobs:    []pattern.Job[int]{{ID: 1, Value: -1}},
+				process: squareNonNegative,
+			},
+			cancel: true,
+			want:   []pattern.Result[int, int]{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			if tt.cancel {
+				cancel() // cancel the context before waiting for the result
+			}
+
+			jobsChan := make(chan pattern.Job[int], len(tt.args.jobs))
+			for _, job := range tt.args.jobs {
+				jobsChan <- job
+			}
+			close(jobsChan)
+
+			var gotResults []pattern.Result[int, int]
+			for result := range BoundedFanOut(ctx, tt.args.limit, jobsChan, tt.args.process) {
+				gotResults = append(gotResults, result)
+			}
+			assert.ElementsMatch(t, tt.want, gotResults)
+		})
+	}
+}
+
+func TestBoundedFanOut_Limit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	limit := 4
+	var running, peak atomic.Int64
+	tracked := func(ctx context.Context, value int) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return squareNonNegative(ctx, value)
+	}
+
+	jobsChan := make(chan pattern.Job[int])
+	go func() {
+		defer close(jobsChan)
+		for i := 1; i <= 100; i++ {
+			jobsChan <- pattern.Job[int]{ID: i, Value: i}
+		}
+	}()
+
+	count := 0
+	for range BoundedFanOut(ctx, limit, jobsChan, tracked) {
+		count++
+	}
+	assert.Equal(t, 100, count)
+	assert.LessOrEqual(t, peak.Load(), int64(limit))
+}
@@ -0,0 +1,160 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoFutures is returned by Any and Race when they are given no future.
+var ErrNoFutures = errors.New("no futures")
+
+// Then returns a future that runs processFunc with the value of f once f succeeds, or fails with the error of f.
+// It runs under its own context: cancelling f makes it fail with context.Canceled, and cancelling it stops waiting
+// for f, which cancels f if nobody else waits for it.
+func Then[T any, U any](f *Future[T], processFunc func(context.Context, T) (U, error)) *Future[U] {
+	return NewFuture(context.Background(), func(ctx context.Context) (U, error) {
+		result := f.Await(ctx)
+		if result.Err != nil {
+			var zero U
+			return zero, result.Err
+		}
+		return processFunc(ctx, result.Value)
+	})
+}
+
+// Map returns a future holding the value of f transformed by mapFunc, or the error of f.
+func Map[T any, U any](f *Future[T], mapFunc func(T) U) *Future[U] {
+	return Then(f, func(_ context.Context, value T) (U, error) {
+		return mapFunc(value), nil
+	})
+}
+
+// All returns a future holding the values of all the futures, in order. It fails with the first error and then
+// cancels the futures still running.
+//
+// Like Any, Race and WithTimeout, it runs under its own context, and cancelling it cancels the futures it waits for
+// that are not resolved yet. Resolved futures are never cancelled, so they can still be chained with Then or Map.
+func All[T any](futures ...*Future[T]) *Future[[]T] {
+	return NewFuture(context.Background(), func(ctx context.Context) ([]T, error) {
+		defer cancelAll(futures)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		completed := completions(ctx, futures)
+
+		values := make([]T, len(futures))
+		for range futures {
+			f, err := next(ctx, completed)
+			if err != nil {
+				return nil, err
+			}
+			if f.result.Err != nil {
+				return nil, f.result.Err
+			}
+		}
+		for i, f := range futures {
+			values[i] = f.result.Value
+		}
+		return values, nil
+	})
+}
+
+// Any returns a future holding the first successful value, and cancels the other futures. It fails with the errors
+// of all the futures joined if none of them succeeds.
+func Any[T any](futures ...*Future[T]) *Future[T] {
+	return NewFuture(context.Background(), func(ctx context.Context) (T, error) {
+		defer cancelAll(futures)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		completed := completions(ctx, futures)
+
+		var zero T
+		if len(futures) == 0 {
+			return zero, ErrNoFutures
+		}
+		for range futures {
+			f, err := next(ctx, completed)
+			if err != nil {
+				return zero, err
+			}
+			if f.result.Err == nil {
+				return f.result.Value, nil
+			}
+		}
+
+		errs := make([]error, 0, len(futures))
+		for _, f := range futures {
+			errs = append(errs, f.result.Err)
+		}
+		return zero, errors.Join(errs...)
+	})
+}
+
+// Race returns a future holding the result of the first future to complete, successful or not, and cancels the
+// other futures.
+func Race[T any](futures ...*Future[T]) *Future[T] {
+	return NewFuture(context.Background(), func(ctx context.Context) (T, error) {
+		defer cancelAll(futures)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		completed := completions(ctx, futures)
+
+		var zero T
+		if len(futures) == 0 {
+			return zero, ErrNoFutures
+		}
+		f, err := next(ctx, completed)
+		if err != nil {
+			return zero, err
+		}
+		return f.result.Value, f.result.Err
+	})
+}
+
+// WithTimeout returns a future holding the result of f, or failing with context.DeadlineExceeded if f does not
+// complete within timeout, in which case f is cancelled.
+func WithTimeout[T any](f *Future[T], timeout time.Duration) *Future[T] {
+	return NewFuture(context.Background(), func(ctx context.Context) (T, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result := f.Await(ctx)
+		if ctx.Err() != nil {
+			f.Cancel()
+		}
+		return result.Value, result.Err
+	})
+}
+
+// completions sends the futures on the returned channel in the order they complete, until the context is done.
+func completions[T any](ctx context.Context, futures []*Future[T]) <-chan *Future[T] {
+	completed := make(chan *Future[T], len(futures)) // Buffered channel to prevent blocking.
+	for _, f := range futures {
+		go func(f *Future[T]) {
+			if f.Await(ctx); ctx.Err() == nil {
+				completed <- f
+			}
+		}(f)
+	}
+	return completed
+}
+
+// next returns the next completed future, or the context error once it is done.
+func next[T any](ctx context.Context, completed <-chan *Future[T]) (*Future[T], error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case f := <-completed:
+		return f, nil
+	}
+}
+
+// cancelAll cancels the futures that are not resolved yet, the losers of a combinator that failed or
+// short-circuited. Once a combinator succeeds every future it waited for is resolved, so nothing is cancelled.
+func cancelAll[T any](futures []*Future[T]) {
+	for _, f := range futures {
+		if _, ok := f.TryResult(); !ok {
+			f.Cancel()
+		}
+	}
+}
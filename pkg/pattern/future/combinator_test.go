@@ -0,0 +1,170 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ErrOther = errors.New("other error")
+
+// after returns a computation that returns value and err after delay, or the context error if it is done first.
+func after[T any](delay time.Duration, value T, err error) ProcessFunc[T] {
+	return func(ctx context.Context) (T, error) {
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-time.After(delay):
+			return value, err
+		}
+	}
+}
+
+func TestFuture_Memoised(t *testing.T) {
+	f := NewFuture(context.Background(), after(10*time.Millisecond, 42, nil))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, Result[int]{Value: 42}, f.Result())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, Result[int]{Value: 42}, f.Result())
+}
+
+func TestThen(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Future[int]
+		want Result[string]
+	}{
+		{
+			name: "Chained",
+			f:    NewFuture(context.Background(), after(time.Millisecond, 21, nil)),
+			want: Result[string]{Value: "42!"},
+		},
+		{
+			name: "Error is propagated",
+			f:    NewFuture(context.Background(), after(time.Millisecond, 0, ErrTest)),
+			want: Result[string]{Err: ErrTest},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doubled := Then(tt.f, func(_ context.Context, value int) (int, error) {
+				return value * 2, nil
+			})
+			formatted := Map(doubled, func(value int) string {
+				return strconv.Itoa(value) + "!"
+			})
+
+			assert.Equal(t, tt.want, formatted.Result())
+		})
+	}
+}
+
+func TestThen_CancelledWithParent(t *testing.T) {
+	f := NewFuture(context.Background(), after(time.Hour, 1, nil))
+	then := Map(f, func(value int) int { return value })
+
+	f.Cancel()
+	assert.ErrorIs(t, then.Result().Err, context.Canceled)
+}
+
+func TestThen_AfterCombinators(t *testing.T) {
+	t.Run("All", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(10*time.Millisecond, 21, nil))
+		then := Then(f, func(_ context.Context, value int) (int, error) { return value * 2, nil })
+
+		assert.Equal(t, Result[[]int]{Value: []int{21}}, All(f).Result())
+		assert.Equal(t, Result[int]{Value: 42}, then.Result(), "a pending chain should survive All")
+	})
+
+	t.Run("Race", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(time.Millisecond, 21, nil))
+
+		assert.Equal(t, Result[int]{Value: 21}, Race(f).Result())
+		assert.Equal(t, Result[int]{Value: 42}, Map(f, func(value int) int { return value * 2 }).Result(),
+			"a late chain should survive Race")
+	})
+}
+
+func TestAll(t *testing.T) {
+	t.Run("All succeed", func(t *testing.T) {
+		all := All(
+			NewFuture(context.Background(), after(20*time.Millisecond, 1, nil)),
+			NewFuture(context.Background(), after(time.Millisecond, 2, nil)),
+			NewFuture(context.Background(), after(10*time.Millisecond, 3, nil)),
+		)
+		assert.Equal(t, Result[[]int]{Value: []int{1, 2, 3}}, all.Result())
+	})
+
+	t.Run("First error cancels the others", func(t *testing.T) {
+		slow := NewFuture(context.Background(), after(time.Hour, 1, nil))
+		all := All(slow, NewFuture(context.Background(), after(time.Millisecond, 0, ErrTest)))
+
+		assert.Equal(t, Result[[]int]{Err: ErrTest}, all.Result())
+		assert.ErrorIs(t, slow.Result().Err, context.Canceled)
+	})
+
+	t.Run("No futures", func(t *testing.T) {
+		assert.Equal(t, Result[[]int]{Value: []int{}}, All[int]().Result())
+	})
+}
+
+func TestAny(t *testing.T) {
+	t.Run("First success wins", func(t *testing.T) {
+		slow := NewFuture(context.Background(), after(time.Hour, 1, nil))
+		failed := NewFuture(context.Background(), after(time.Millisecond, 0, ErrTest))
+		fast := NewFuture(context.Background(), after(10*time.Millisecond, 3, nil))
+
+		assert.Equal(t, Result[int]{Value: 3}, Any(slow, failed, fast).Result())
+		assert.ErrorIs(t, slow.Result().Err, context.Canceled, "losers should be cancelled")
+	})
+
+	t.Run("All fail", func(t *testing.T) {
+		result := Any(
+			NewFuture(context.Background(), after(time.Millisecond, 0, ErrTest)),
+			NewFuture(context.Background(), after(2*time.Millisecond, 0, ErrOther)),
+		).Result()
+
+		assert.ErrorIs(t, result.Err, ErrTest)
+		assert.ErrorIs(t, result.Err, ErrOther)
+	})
+
+	t.Run("No futures", func(t *testing.T) {
+		assert.ErrorIs(t, Any[int]().Result().Err, ErrNoFutures)
+	})
+}
+
+func TestRace(t *testing.T) {
+	slow := NewFuture(context.Background(), after(time.Hour, 1, nil))
+	failed := NewFuture(context.Background(), after(time.Millisecond, 0, ErrTest))
+
+	assert.Equal(t, Result[int]{Err: ErrTest}, Race(slow, failed).Result())
+	assert.ErrorIs(t, slow.Result().Err, context.Canceled, "losers should be cancelled")
+	assert.ErrorIs(t, Race[int]().Result().Err, ErrNoFutures)
+}
+
+func TestWithTimeout(t *testing.T) {
+	t.Run("Completes in time", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(time.Millisecond, 42, nil))
+		assert.Equal(t, Result[int]{Value: 42}, WithTimeout(f, time.Second).Result())
+	})
+
+	t.Run("Times out", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(time.Hour, 42, nil))
+		assert.Equal(t, Result[int]{Err: context.DeadlineExceeded}, WithTimeout(f, 10*time.Millisecond).Result())
+		assert.ErrorIs(t, f.Result().Err, context.Canceled, "the timed out future should be cancelled")
+	})
+}
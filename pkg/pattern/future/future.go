@@ -0,0 +1,136 @@
+// Package future implements the future pattern.
+package future
+
+import (
+	"context"
+	"sync"
+)
+
+// Result type represents a computation result.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Future type represents a future value.
+//
+// The result is memoised: it is computed once and every call to Result returns it. The future resolves with the
+// context error as soon as its context is done, even if the computation does not return yet. Once every caller
+// waiting in Await gave up, the context is cancelled, since nobody is interested in the result anymore, unless the
+// future belongs to a Promise.
+type Future[T any] struct {
+	ctx    context.Context    // ctx is the context the computation runs under.
+	cancel context.CancelFunc // cancel cancels ctx, it is how combinators stop the futures they no longer need.
+
+	done   chan struct{} // done is closed once the result is set.
+	once   sync.Once
+	result Result[T]
+
+	mu      sync.Mutex
+	waiters int         // waiters counts the callers waiting for the result.
+	stop    func() bool // stop stops resolving the future once ctx is done.
+
+	settledByPromise bool // settledByPromise keeps the future pending when its waiters give up, only Cancel settles it.
+}
+
+// ProcessFunc defines a function type for processing a value of type T to produce a value of type U, in a context-aware manner.
+type ProcessFunc[T any] func(context.Context) (T, error)
+
+// NewFuture creates a new Future.
+func NewFuture[T any](ctx context.Context, processFunc ProcessFunc[T]) *Future[T] {
+	f := newFuture[T](ctx)
+	go func() {
+		if f.ctx.Err() != nil {
+			return // Context already canceled, do not start the computation.
+		}
+		value, err := processFunc(f.ctx)
+		f.complete(Result[T]{Value: value, Err: err}) // Send processFunc result.
+	}()
+	return f
+}
+
+// newFuture creates a Future without a computation, it is resolved by calling complete, or with the context error
+// once ctx is done. The context is released once the future is resolved.
+func newFuture[T any](ctx context.Context) *Future[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future[T]{ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	f.mu.Lock() // The function may run right away if ctx is already done, and complete reads stop.
+	defer f.mu.Unlock()
+	f.stop = context.AfterFunc(ctx, func() {
+		f.complete(Result[T]{Err: ctx.Err()}) // Send context error as soon as it is canceled.
+	})
+	return f
+}
+
+// complete sets the result if it is not set yet, and reports whether it did.
+func (f *Future[T]) complete(result Result[T]) bool {
+	completed := false
+	f.once.Do(func() {
+		f.mu.Lock()
+		f.stop()
+		f.mu.Unlock()
+		f.result = result
+		close(f.done)
+		f.cancel() // Release the context, the result is set and nothing runs under it anymore.
+		completed = true
+	})
+	return completed
+}
+
+// Result retrieves the result of the computation.
+func (f *Future[T]) Result() Result[T] {
+	return f.Await(context.Background()) // This will block until the result is ready.
+}
+
+// Await waits for the result of the computation, or returns the context error once ctx is done.
+// When the last waiter gives up before the result is ready, the computation is cancelled. The future then stays
+// failed with context.Canceled for every later Await and Result caller: one Await with a short timeout is enough
+// to give up on the result for good. The future of a Promise is not cancelled, it waits for the promise to settle.
+func (f *Future[T]) Await(ctx context.Context) Result[T] {
+	if result, ok := f.TryResult(); ok {
+		return result // A ready result wins over a done context.
+	}
+
+	f.mu.Lock()
+	f.waiters++
+	f.mu.Unlock()
+
+	select {
+	case <-f.done:
+		f.mu.Lock()
+		f.waiters--
+		f.mu.Unlock()
+		return f.result
+	case <-ctx.Done():
+		f.mu.Lock()
+		f.waiters--
+		last := f.waiters == 0 && !f.settledByPromise
+		f.mu.Unlock()
+		if last {
+			f.Cancel()
+		}
+		return Result[T]{Err: ctx.Err()}
+	}
+}
+
+// TryResult returns the result of the computation if it is ready, without blocking.
+func (f *Future[T]) TryResult() (Result[T], bool) {
+	select {
+	case <-f.done:
+		return f.result, true
+	default:
+		return Result[T]{}, false
+	}
+}
+
+// Done returns a channel that is closed once the result is ready.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel cancels the context of the computation, the future then resolves with context.Canceled if it is not
+// resolved yet. Cancelling a resolved future has no effect.
+func (f *Future[T]) Cancel() {
+	f.cancel()
+	f.complete(Result[T]{Err: f.ctx.Err()}) // Resolve right away rather than when the context callback runs.
+}
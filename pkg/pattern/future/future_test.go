@@ -0,0 +1,174 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ErrTest = errors.New("test error")
+
+func TestFuture(t *testing.T) {
+	type args struct {
+		computation ProcessFunc[int]
+	}
+	type testCase struct {
+		name   string
+		args   args
+		cancel bool        // whether to cancel the context before waiting for the result
+		want   Result[int] // expected result
+	}
+
+	tests := []testCase{
+		{
+			name: "Successful Computation",
+			args: args{
+				computation: func(ctx context.Context) (int, error) {
+					return 42, nil
+				},
+			},
+			want: Result[int]{Value: 42},
+		},
+		{
+			name: "Computation Error",
+			args: args{
+				computation: func(ctx context.Context) (int, error) {
+					return 0, ErrTest
+				},
+			},
+			want: Result[int]{Err: ErrTest},
+		},
+		{
+			name: "Context Cancellation",
+			args: args{
+				computation: func(ctx context.Context) (int, error) {
+					time.Sleep(200 * time.Millisecond) // simulate a longer computation
+					return 42, nil
+				},
+			},
+			cancel: true,
+			want:   Result[int]{Err: context.Canceled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			if tt.cancel {
+				cancel() // cancel the context before waiting for the result
+			}
+
+			future := NewFuture(ctx, tt.args.computation)
+			got := future.Result()
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFuture_Await(t *testing.T) {
+	t.Run("Waiter gives up", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(time.Hour, 42, nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, Result[int]{Err: context.DeadlineExceeded}, f.Await(ctx))
+
+		// the only waiter gave up, so the computation is cancelled and late waiters get the cancellation
+		assert.Equal(t, Result[int]{Err: context.Canceled}, f.Await(context.Background()))
+	})
+
+	t.Run("Await after a timed out one", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(20*time.Millisecond, 42, nil))
+
+		short, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		assert.Equal(t, Result[int]{Err: context.DeadlineExceeded}, f.Await(short))
+
+		// the computation would have completed in time for this waiter, but it was cancelled for good
+		long, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.Equal(t, Result[int]{Err: context.Canceled}, f.Await(long))
+		assert.Equal(t, Result[int]{Err: context.Canceled}, f.Result())
+	})
+
+	t.Run("Other waiters keep the computation running", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(20*time.Millisecond, 42, nil))
+
+		patient := make(chan Result[int])
+		go func() { patient <- f.Await(context.Background()) }()
+		assert.Eventually(t, func() bool {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.waiters == 1
+		}, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Equal(t, Result[int]{Err: context.Canceled}, f.Await(ctx))
+		assert.Equal(t, Result[int]{Value: 42}, <-patient)
+	})
+
+	t.Run("Late waiters and repeated reads", func(t *testing.T) {
+		f := NewFuture(context.Background(), after(time.Millisecond, 42, nil))
+		<-f.Done()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, Result[int]{Value: 42}, f.Result())
+			assert.Equal(t, Result[int]{Value: 42}, f.Await(context.Background()))
+			assert.Equal(t, Result[int]{Value: 42}, f.Await(ctx), "a ready result wins over a done context")
+		}
+	})
+}
+
+func TestFuture_TryResult(t *testing.T) {
+	release := make(chan struct{})
+	f := NewFuture(context.Background(), func(ctx context.Context) (int, error) {
+		<-release
+		return 42, nil
+	})
+
+	_, ok := f.TryResult()
+	assert.False(t, ok)
+	select {
+	case <-f.Done():
+		t.Fatal("future should not be done yet")
+	default:
+	}
+
+	close(release)
+	<-f.Done()
+	result, ok := f.TryResult()
+	assert.True(t, ok)
+	assert.Equal(t, Result[int]{Value: 42}, result)
+}
+
+func TestFuture_ReleasesContextOnCompletion(t *testing.T) {
+	f := NewFuture(context.Background(), after(time.Millisecond, 42, nil))
+
+	assert.Equal(t, Result[int]{Value: 42}, f.Result())
+	assert.Error(t, f.ctx.Err(), "the context should be released once the future is resolved")
+	f.Cancel()
+	assert.Equal(t, Result[int]{Value: 42}, f.Result(), "cancelling a resolved future has no effect")
+}
+
+func TestFuture_ResolvesOnCancelWithoutWaitingForComputation(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := NewFuture(ctx, func(context.Context) (int, error) {
+		<-release // ignores its context
+		return 42, nil
+	})
+
+	cancel()
+	assert.Equal(t, Result[int]{Err: context.Canceled}, f.Result())
+}
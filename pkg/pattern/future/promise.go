@@ -0,0 +1,45 @@
+package future
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadySettled is returned when resolving or rejecting a promise that is already settled.
+var ErrAlreadySettled = errors.New("promise already settled")
+
+// Promise is the write side of a Future, for values that come from a callback, such as a UI event or a pubsub
+// message, rather than from a ProcessFunc. It can be settled once, by either Resolve or Reject.
+type Promise[T any] struct {
+	future *Future[T]
+}
+
+// NewPromise creates a new Promise.
+func NewPromise[T any]() *Promise[T] {
+	f := newFuture[T](context.Background())
+	f.settledByPromise = true // Readers giving up must not take the value away from the producer.
+	return &Promise[T]{future: f}
+}
+
+// Resolve settles the promise with a value, or returns ErrAlreadySettled.
+func (p *Promise[T]) Resolve(value T) error {
+	return p.settle(Result[T]{Value: value})
+}
+
+// Reject settles the promise with an error, or returns ErrAlreadySettled.
+func (p *Promise[T]) Reject(err error) error {
+	return p.settle(Result[T]{Err: err})
+}
+
+// Future returns the future of the promise. Cancelling it settles the promise with context.Canceled, while callers
+// giving up in Await leave it pending.
+func (p *Promise[T]) Future() *Future[T] {
+	return p.future
+}
+
+func (p *Promise[T]) settle(result Result[T]) error {
+	if !p.future.complete(result) {
+		return ErrAlreadySettled
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package future
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromise(t *testing.T) {
+	tests := []struct {
+		name   string
+		settle func(p *Promise[int]) error
+		want   Result[int]
+	}{
+		{
+			name:   "Resolve",
+			settle: func(p *Promise[int]) error { return p.Resolve(42) },
+			want:   Result[int]{Value: 42},
+		},
+		{
+			name:   "Reject",
+			settle: func(p *Promise[int]) error { return p.Reject(ErrTest) },
+			want:   Result[int]{Err: ErrTest},
+		},
+		{
+			name: "Cancel",
+			settle: func(p *Promise[int]) error {
+				p.Future().Cancel()
+				<-p.Future().Done()
+				return nil
+			},
+			want: Result[int]{Err: context.Canceled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPromise[int]()
+			_, ok := p.Future().TryResult()
+			assert.False(t, ok)
+
+			// settle from a callback, while the result is awaited
+			go func() {
+				time.Sleep(time.Millisecond)
+				assert.NoError(t, tt.settle(p))
+			}()
+			assert.Equal(t, tt.want, p.Future().Result())
+
+			assert.ErrorIs(t, p.Resolve(1), ErrAlreadySettled)
+			assert.ErrorIs(t, p.Reject(ErrOther), ErrAlreadySettled)
+			assert.Equal(t, tt.want, p.Future().Result(), "a settled promise should not change")
+		})
+	}
+}
+
+func TestPromise_WithCombinators(t *testing.T) {
+	p := NewPromise[int]()
+	doubled := Map(p.Future(), func(value int) int { return value * 2 })
+
+	assert.NoError(t, p.Resolve(21))
+	assert.Equal(t, Result[int]{Value: 42}, doubled.Result())
+}
+
+func TestPromise_AwaitTimeoutLeavesPending(t *testing.T) {
+	p := NewPromise[int]()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.Equal(t, Result[int]{Err: context.DeadlineExceeded}, p.Future().Await(ctx))
+
+	// the only waiter gave up, the producer can still settle the promise
+	assert.NoError(t, p.Resolve(42))
+	assert.ErrorIs(t, p.Resolve(43), ErrAlreadySettled)
+	assert.Equal(t, Result[int]{Value: 42}, p.Future().Await(context.Background()))
+}
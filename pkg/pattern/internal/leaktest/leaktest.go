@@ -0,0 +1,44 @@
+// Package leaktest checks that tests do not leave goroutines behind, in the spirit of go.uber.org/goleak.
+package leaktest
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Verify fails the test if goroutines running code of the calling package are still running once the test is done,
+// ignoring the goroutines started by the tests themselves. It is meant to be deferred at the start of a test:
+//
+//	defer leaktest.Verify(t)
+func Verify(t testing.TB) {
+	t.Helper()
+
+	pkg := callerPackage()
+	var leaked []string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		leaked = leaked[:0]
+		buf := make([]byte, 1<<20)
+		for _, stack := range strings.Split(string(buf[:runtime.Stack(buf, true)]), "\n\n") {
+			if strings.Contains(stack, pkg+".") && !strings.Contains(stack, pkg+".Test") {
+				leaked = append(leaked, stack)
+			}
+		}
+		if len(leaked) == 0 {
+			return
+		}
+	}
+	t.Errorf("found %d leaked goroutines:\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+}
+
+// callerPackage returns the import path of the package calling Verify.
+func callerPackage() string {
+	pc, _, _, _ := runtime.Caller(2)
+	name := runtime.FuncForPC(pc).Name() // e.g. github.com/user/repo/pkg.TestName.func1
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+	return name
+}
@@ -0,0 +1,22 @@
+// Package pattern holds the vocabulary shared by the worker pool packages: fanoutin, workerpool and dynamic.
+// The pipeline, future and pubsub packages carry values rather than jobs and define their own Result types.
+package pattern
+
+import "context"
+
+// Job holds information about each job.
+type Job[T any] struct {
+	ID    int
+	Value T
+}
+
+// Result holds information about each result.
+type Result[T any, U any] struct {
+	Job      Job[T]
+	Value    U
+	Err      error
+	Attempts int // Attempts is the number of times the job was processed.
+}
+
+// ProcessFunc defines a function type for processing a value of type T to produce a value of type U, in a context-aware manner.
+type ProcessFunc[T any, U any] func(context.Context, T) (U, error)
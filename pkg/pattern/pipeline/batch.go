@@ -0,0 +1,186 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidWindow is returned by SlidingWindow and TumblingWindow when the window size or slide is not positive.
+var ErrInvalidWindow = errors.New("invalid window")
+
+// flushGrace is how long a final flush waits for the consumer once the context is done, before it is dropped.
+const flushGrace = 100 * time.Millisecond
+
+// Window is a group of items that arrived between Start, excluded, and End, included.
+type Window[T any] struct {
+	Start time.Time
+	End   time.Time
+	Items []T
+}
+
+// Batch groups the values of inCh into batches of up to size values, emitting a partial batch once maxWait has passed
+// since its first value; a zero maxWait only emits full batches.
+//
+// Results with an error are not batched, they are forwarded on their own as soon as they arrive.
+// When inCh is closed the partial batch is emitted, and when the context is done it is flushed as well:
+// the consumer should read the output channel until it is closed, a flush not read within flushGrace of the
+// context being done is dropped.
+func Batch[T any](ctx context.Context, inCh <-chan Result[T], size int, maxWait time.Duration) <-chan Result[[]T] {
+	size = max(size, 1)
+	outCh := make(chan Result[[]T])
+	go func() {
+		defer close(outCh)
+
+		var batch []T
+		timer := time.NewTimer(maxWait)
+		timer.Stop()
+		var timeout <-chan time.Time // timeout is nil while there is no partial batch.
+
+		// emit sends the batch, it is kept for the final flush if the context is done first.
+		emit := func() {
+			if !timer.Stop() {
+				// The timer may have fired while the batch filled up, drain the stale tick so that it does not
+				// cut the next batch short.
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timeout = nil
+			if send(ctx, outCh, Result[[]T]{Value: batch}) {
+				batch = nil
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				if len(batch) > 0 {
+					flush(ctx, outCh, Result[[]T]{Value: batch}) // flush the partial batch
+				}
+				return
+			case <-timeout:
+				emit()
+			case in, ok := <-inCh:
+				if !ok {
+					if len(batch) > 0 {
+						flush(ctx, outCh, Result[[]T]{Value: batch})
+					}
+					return // input channel closed, exit
+				}
+				if in.Err != nil {
+					send(ctx, outCh, Result[[]T]{Err: in.Err})
+					continue
+				}
+				if len(batch) == 0 && maxWait > 0 {
+					timer.Reset(maxWait)
+					timeout = timer.C
+				}
+				batch = append(batch, in.Value)
+				if len(batch) >= size {
+					emit()
+				}
+			}
+		}
+	}()
+	return outCh
+}
+
+// TumblingWindow groups the values of inCh into consecutive, non-overlapping windows of the given size, by arrival time.
+// It is a SlidingWindow that slides by its own size.
+func TumblingWindow[T any](ctx context.Context, inCh <-chan Result[T], size time.Duration) (<-chan Result[Window[T]], error) {
+	return SlidingWindow(ctx, inCh, size, size)
+}
+
+// SlidingWindow emits, every slide, the values of inCh that arrived during the last size; with a slide shorter than
+// size a value is part of several windows. Empty windows are not emitted.
+//
+// Results with an error are forwarded on their own as soon as they arrive.
+// When inCh is closed or the context is done, values not yet emitted in any window are flushed in a final window:
+// the consumer should read the output channel until it is closed, a flush not read within flushGrace of the
+// context being done is dropped.
+//
+// It returns ErrInvalidWindow if size or slide is not positive.
+func SlidingWindow[T any](ctx context.Context, inCh <-chan Result[T], size, slide time.Duration) (<-chan Result[Window[T]], error) {
+	if size <= 0 || slide <= 0 {
+		return nil, fmt.Errorf("%w: size %v and slide %v must be positive", ErrInvalidWindow, size, slide)
+	}
+
+	type arrival struct {
+		at    time.Time
+		value T
+	}
+
+	outCh := make(chan Result[Window[T]])
+	go func() {
+		defer close(outCh)
+
+		origin := time.Now()
+		ticker := time.NewTicker(slide)
+		defer ticker.Stop()
+
+		var arrivals []arrival
+		fresh := false // fresh reports whether some arrivals were not emitted yet.
+
+		window := func(end time.Time) Window[T] {
+			w := Window[T]{Start: end.Add(-size), End: end}
+			for _, a := range arrivals {
+				if a.at.After(w.Start) && !a.at.After(end) {
+					w.Items = append(w.Items, a.value)
+				}
+			}
+			// drop the arrivals that will not be part of the next window
+			next := w.Start.Add(slide)
+			for len(arrivals) > 0 && !arrivals[0].at.After(next) {
+				arrivals = arrivals[1:]
+			}
+			return w
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				if fresh {
+					flush(ctx, outCh, Result[Window[T]]{Value: window(time.Now())}) // flush the last window
+				}
+				return
+			case now := <-ticker.C:
+				// align the window end to the slide, a tick may be delivered late
+				end := origin.Add(now.Sub(origin).Truncate(slide))
+				if w := window(end); len(w.Items) > 0 && send(ctx, outCh, Result[Window[T]]{Value: w}) {
+					fresh = false
+				}
+			case in, ok := <-inCh:
+				if !ok {
+					if fresh {
+						flush(ctx, outCh, Result[Window[T]]{Value: window(time.Now())})
+					}
+					return // input channel closed, exit
+				}
+				if in.Err != nil {
+					send(ctx, outCh, Result[Window[T]]{Err: in.Err})
+					continue
+				}
+				arrivals = append(arrivals, arrival{at: time.Now(), value: in.Value})
+				fresh = true
+			}
+		}
+	}()
+	return outCh, nil
+}
+
+// flush sends a final value on ch. Once the context is done it still waits up to flushGrace for the consumer, so that
+// the value is delivered to a consumer that keeps reading, and dropped rather than leaked otherwise.
+func flush[T any](ctx context.Context, ch chan<- T, value T) {
+	if send(ctx, ch, value) {
+		return
+	}
+	timer := time.NewTimer(flushGrace)
+	defer timer.Stop()
+	select {
+	case ch <- value:
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,214 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/internal/leaktest"
+)
+
+// collect reads a channel until it is closed.
+func collect[T any](ch <-chan Result[T]) []Result[T] {
+	var results []Result[T]
+	for res := range ch {
+		results = append(results, res)
+	}
+	return results
+}
+
+func TestBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []Result[int]
+		size     int
+		expected []Result[[]int]
+	}{
+		{
+			name:     "Full and partial batches",
+			input:    []Result[int]{{Value: 1}, {Value: 2}, {Value: 3}, {Value: 4}, {Value: 5}, {Value: 6}, {Value: 7}},
+			size:     3,
+			expected: []Result[[]int]{{Value: []int{1, 2, 3}}, {Value: []int{4, 5, 6}}, {Value: []int{7}}},
+		},
+		{
+			name:     "Errors are forwarded on their own",
+			input:    []Result[int]{{Value: 1}, {Err: ErrAtValue3}, {Value: 2}},
+			size:     2,
+			expected: []Result[[]int]{{Err: ErrAtValue3}, {Value: []int{1, 2}}},
+		},
+		{
+			name:     "Empty input",
+			size:     2,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer leaktest.Verify(t)
+
+			inputCh := make(chan Result[int], len(tt.input))
+			for _, in := range tt.input {
+				inputCh <- in
+			}
+			close(inputCh)
+
+			assert.Equal(t, tt.expected, collect(Batch(context.Background(), inputCh, tt.size, 0)))
+		})
+	}
+}
+
+func TestBatch_MaxWait(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	inputCh := make(chan Result[int])
+	outCh := Batch(context.Background(), inputCh, 10, 20*time.Millisecond)
+
+	inputCh <- Result[int]{Value: 1}
+	inputCh <- Result[int]{Value: 2}
+	assert.Equal(t, Result[[]int]{Value: []int{1, 2}}, <-outCh, "partial batch should be emitted after max wait")
+
+	inputCh <- Result[int]{Value: 3}
+	close(inputCh)
+	assert.Equal(t, []Result[[]int]{{Value: []int{3}}}, collect(outCh))
+}
+
+// TestBatch_FullAtMaxWait guards against a stale timer tick cutting the next batch short, which can only happen with
+// the asynchronous timer channels of Go toolchains before 1.23.
+func TestBatch_FullAtMaxWait(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	const maxWait = 30 * time.Millisecond
+	inputCh := make(chan Result[int], 3)
+	outCh := Batch(context.Background(), inputCh, 2, maxWait)
+	defer close(inputCh)
+
+	for i := 0; i < 10; i++ {
+		// the error holds the stage while the timer of the first value fires, so that the batch may fill up
+		// with a stale tick pending
+		inputCh <- Result[int]{Value: 1}
+		inputCh <- Result[int]{Err: ErrAtValue3}
+		inputCh <- Result[int]{Value: 2}
+		time.Sleep(2 * maxWait)
+		assert.ErrorIs(t, (<-outCh).Err, ErrAtValue3)
+		for values := 0; values < 2; {
+			values += len((<-outCh).Value)
+		}
+
+		inputCh <- Result[int]{Value: 3}
+		select {
+		case res := <-outCh:
+			t.Fatalf("batch %v emitted before max wait", res.Value)
+		case <-time.After(maxWait / 2):
+		}
+		assert.Equal(t, Result[[]int]{Value: []int{3}}, <-outCh)
+	}
+}
+
+func TestBatch_FlushOnCancel(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inputCh := make(chan Result[int]) // never closed, the stage only ends when cancelled
+	outCh := Batch(ctx, inputCh, 10, time.Hour)
+
+	inputCh <- Result[int]{Value: 1}
+	inputCh <- Result[int]{Value: 2}
+	cancel()
+
+	assert.Equal(t, []Result[[]int]{{Value: []int{1, 2}}}, collect(outCh))
+}
+
+func TestBatch_DropsFlushWithoutConsumer(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inputCh := make(chan Result[int])
+	Batch(ctx, inputCh, 10, time.Hour) // the output channel is never read
+
+	inputCh <- Result[int]{Value: 1}
+	cancel()
+}
+
+func TestTumblingWindow(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	inputCh := make(chan Result[int])
+	outCh, err := TumblingWindow(context.Background(), inputCh, 100*time.Millisecond)
+	assert.NoError(t, err)
+
+	go func() {
+		defer close(inputCh)
+		inputCh <- Result[int]{Value: 1}
+		inputCh <- Result[int]{Value: 2}
+		inputCh <- Result[int]{Value: 3}
+		time.Sleep(150 * time.Millisecond)
+		inputCh <- Result[int]{Err: ErrAtValue3}
+		inputCh <- Result[int]{Value: 4}
+		inputCh <- Result[int]{Value: 5}
+	}()
+
+	var items [][]int
+	for res := range outCh {
+		if res.Err != nil {
+			assert.ErrorIs(t, res.Err, ErrAtValue3)
+			continue
+		}
+		assert.Equal(t, res.Value.Start.Add(100*time.Millisecond), res.Value.End)
+		items = append(items, res.Value.Items)
+	}
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5}}, items)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	inputCh := make(chan Result[int])
+	outCh, err := SlidingWindow(context.Background(), inputCh, 100*time.Millisecond, 25*time.Millisecond)
+	assert.NoError(t, err)
+
+	go func() {
+		defer close(inputCh)
+		inputCh <- Result[int]{Value: 1}
+		time.Sleep(150 * time.Millisecond)
+	}()
+
+	windows := 0
+	for res := range outCh {
+		assert.NoError(t, res.Err)
+		assert.Equal(t, []int{1}, res.Value.Items)
+		windows++
+	}
+	assert.GreaterOrEqual(t, windows, 2, "a value should be part of several overlapping windows")
+	assert.LessOrEqual(t, windows, 4, "a value should only be part of the windows covering its arrival")
+}
+
+func TestSlidingWindow_FlushOnCancel(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inputCh := make(chan Result[int]) // never closed, the stage only ends when cancelled
+	outCh, err := TumblingWindow(ctx, inputCh, time.Hour)
+	assert.NoError(t, err)
+
+	inputCh <- Result[int]{Value: 1}
+	cancel()
+
+	results := collect(outCh)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, []int{1}, results[0].Value.Items)
+	}
+}
+
+func TestSlidingWindow_InvalidDurations(t *testing.T) {
+	inputCh := make(chan Result[int])
+
+	_, err := SlidingWindow(context.Background(), inputCh, time.Second, 0)
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+	_, err = SlidingWindow(context.Background(), inputCh, -time.Second, time.Second)
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+	_, err = TumblingWindow(context.Background(), inputCh, 0)
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// Pipeline runs a graph of typed stages under a single context.
+//
+// Stages are chained with From and Then, and the last stage's Out channel must be consumed until it is closed, or the
+// pipeline stopped, for the stages to finish. What happens to the results a stage returns with an error depends on
+// the ErrorPolicy of the pipeline, or of the stage when it overrides it. By default they travel downstream as
+// Result.Err, like with Pipe, and each stage decides what to do with them.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	policy ErrorPolicy
+
+	skipped   atomic.Int64
+	mu        sync.Mutex
+	collected []error
+
+	waitOnce sync.Once
+	err      error // err is the outcome of the pipeline, set by the first Wait.
+}
+
+// Stage is a typed stage of a Pipeline.
+type Stage[T any] struct {
+	pipeline *Pipeline
+	out      <-chan Result[T]
+	skipped  atomic.Int64
+}
+
+// Option configures a Pipeline.
+type Option func(*Pipeline)
+
+// WithErrorPolicy sets the default error policy of the pipeline stages, Propagate if not set.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(p *Pipeline) {
+		p.policy = policy
+	}
+}
+
+// New creates a Pipeline that runs under a context derived from ctx.
+func New(ctx context.Context, opts ...Option) *Pipeline {
+	ctx, cancel := context.WithCancelCause(ctx)
+	p := &Pipeline{ctx: ctx, cancel: cancel}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Context returns the context the stages run under.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Stop cancels all stages, Wait then returns context.Canceled.
+func (p *Pipeline) Stop() {
+	p.cancel(nil)
+}
+
+// Wait blocks until all stages have finished. It returns the error that stopped the pipeline, either a FailFast error
+// or the context cause, joined with the errors of the Collect stages. Every call returns the same error.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.waitOnce.Do(func() {
+		errs := []error{context.Cause(p.ctx)}
+		p.cancel(nil) // Release the context, the stages are done.
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.err = errors.Join(append(errs, p.collected...)...)
+	})
+	return p.err
+}
+
+// Skipped returns the number of failed results dropped by the Skip stages.
+func (p *Pipeline) Skipped() int64 {
+	return p.skipped.Load()
+}
+
+// handle applies the policy to a failed result of a stage and reports whether to send it downstream.
+// Errors returned once the pipeline is stopping are a consequence of the stop and are only propagated.
+func (p *Pipeline) handle(policy ErrorPolicy, skipped *atomic.Int64, err error) bool {
+	if p.ctx.Err() != nil {
+		return policy == Propagate
+	}
+
+	switch policy {
+	case FailFast:
+		p.cancel(err)
+		return false
+	case Skip:
+		skipped.Add(1)
+		p.skipped.Add(1)
+		return false
+	case Collect:
+		p.mu.Lock()
+		p.collected = append(p.collected, err)
+		p.mu.Unlock()
+		return false
+	default:
+		return true
+	}
+}
+
+// From starts a pipeline from an existing channel.
+func From[T any](p *Pipeline, inCh <-chan Result[T]) *Stage[T] {
+	return &Stage[T]{pipeline: p, out: inCh}
+}
+
+// FromValues starts a pipeline from a list of values.
+func FromValues[T any](p *Pipeline, values ...T) *Stage[T] {
+	outCh := make(chan Result[T])
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(outCh)
+		for _, value := range values {
+			if !send(p.ctx, outCh, Result[T]{Value: value}) {
+				return
+			}
+		}
+	}()
+	return From(p, outCh)
+}
+
+// Then appends a stage that processes the results of s with processFunc.
+func Then[T any, U any](s *Stage[T], processFunc ProcessFunc[T, U], opts ...StageOption) *Stage[U] {
+	p := s.pipeline
+	next := &Stage[U]{pipeline: p}
+
+	config := newStageConfig(opts...)
+	config.wg = &p.wg
+	policy := p.policy
+	if config.policy != nil {
+		policy = *config.policy
+	}
+	config.keep = func(err error) bool {
+		return p.handle(policy, &next.skipped, err)
+	}
+
+	p.wg.Add(1)
+	next.out = runStage(p.ctx, s.out, processFunc, config)
+	return next
+}
+
+// Skipped returns the number of failed results dropped by the stage under the Skip policy.
+func (s *Stage[T]) Skipped() int64 {
+	return s.skipped.Load()
+}
+
+// Out returns the results of the stage.
+func (s *Stage[T]) Out() <-chan Result[T] {
+	return s.out
+}
@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/internal/leaktest"
+)
+
+// slowSquare squares values, sleeping longer for smaller values so that parallel workers finish out of order.
+func slowSquare(ctx context.Context, res Result[int]) Result[int] {
+	if res.Err != nil {
+		return Result[int]{Err: res.Err}
+	}
+	select {
+	case <-ctx.Done():
+		return Result[int]{Err: ctx.Err()}
+	case <-time.After(time.Duration(10-res.Value%10) * time.Millisecond):
+		return Result[int]{Value: res.Value * res.Value}
+	}
+}
+
+func format(_ context.Context, res Result[int]) Result[string] {
+	if res.Err != nil {
+		return Result[string]{Err: res.Err}
+	}
+	return Result[string]{Value: fmt.Sprint(res.Value)}
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []int
+		opts     []StageOption
+		ordered  bool
+		expected []Result[string]
+	}{
+		{
+			name:     "Single worker",
+			values:   []int{1, 2, 3, 4},
+			expected: []Result[string]{{Value: "1"}, {Value: "4"}, {Value: "9"}, {Value: "16"}},
+			ordered:  true,
+		},
+		{
+			name:     "Parallel unordered",
+			values:   []int{1, 2, 3, 4},
+			opts:     []StageOption{WithWorkers(4), WithBuffer(4)},
+			expected: []Result[string]{{Value: "1"}, {Value: "4"}, {Value: "9"}, {Value: "16"}},
+		},
+		{
+			name:     "Parallel ordered",
+			values:   []int{1, 2, 3, 4, 5, 6, 7, 8},
+			opts:     []StageOption{WithWorkers(3), WithOrder()},
+			expected: []Result[string]{{Value: "1"}, {Value: "4"}, {Value: "9"}, {Value: "16"}, {Value: "25"}, {Value: "36"}, {Value: "49"}, {Value: "64"}},
+			ordered:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer leaktest.Verify(t)
+
+			p := New(context.Background())
+			squared := Then(FromValues(p, tt.values...), slowSquare, tt.opts...)
+			formatted := Then(squared, format)
+
+			var got []Result[string]
+			for res := range formatted.Out() {
+				got = append(got, res)
+			}
+
+			assert.NoError(t, p.Wait())
+			if tt.ordered {
+				assert.Equal(t, tt.expected, got)
+			} else {
+				assert.ElementsMatch(t, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPipeline_ErrorPropagation(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	inputCh := make(chan Result[int], 3)
+	inputCh <- Result[int]{Value: 1}
+	inputCh <- Result[int]{Err: ErrAtValue3}
+	inputCh <- Result[int]{Value: 2}
+	close(inputCh)
+
+	p := New(context.Background())
+	formatted := Then(Then(From(p, inputCh), slowSquare, WithWorkers(2), WithOrder()), format)
+
+	var got []Result[string]
+	for res := range formatted.Out() {
+		got = append(got, res)
+	}
+
+	assert.NoError(t, p.Wait())
+	assert.NoError(t, p.Wait(), "a second Wait should return the same outcome")
+	assert.Equal(t, []Result[string]{{Value: "1"}, {Err: ErrAtValue3}, {Value: "4"}}, got)
+}
+
+func TestPipeline_Stop(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	inputCh := make(chan Result[int]) // never closed, the pipeline only ends when stopped
+	p := New(context.Background())
+	formatted := Then(Then(From(p, inputCh), slowSquare, WithWorkers(4), WithOrder()), format, WithBuffer(1))
+
+	inputCh <- Result[int]{Value: 3}
+	assert.Equal(t, Result[string]{Value: "9"}, <-formatted.Out())
+
+	p.Stop()
+	for range formatted.Out() {
+	}
+	assert.ErrorIs(t, p.Wait(), context.Canceled)
+	assert.ErrorIs(t, p.Wait(), context.Canceled)
+}
+
+func TestPipeline_ParentContextCancelled(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx)
+	formatted := Then(Then(FromValues(p, 1, 2, 3), slowSquare, WithWorkers(2)), format)
+
+	cancel()
+	for range formatted.Out() {
+	}
+	assert.ErrorIs(t, p.Wait(), context.Canceled)
+}
@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// Pair holds the values zipped together by Zip.
+type Pair[T any, U any] struct {
+	First  T
+	Second U
+}
+
+// Merge sends the results of all the input channels on a single channel, which is closed once all of them are closed
+// or the context is done.
+func Merge[T any](ctx context.Context, inChs ...<-chan Result[T]) <-chan Result[T] {
+	outCh := make(chan Result[T])
+
+	var wg sync.WaitGroup
+	for _, inCh := range inChs {
+		wg.Add(1)
+		go func(inCh <-chan Result[T]) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case in, ok := <-inCh:
+					if !ok || !send(ctx, outCh, in) {
+						return
+					}
+				}
+			}
+		}(inCh)
+	}
+
+	go func() {
+		wg.Wait()
+		close(outCh)
+	}()
+	return outCh
+}
+
+// Tee broadcasts every result of inCh to n channels. All of them must be read: the next result is only received once
+// the current one was delivered to every channel, so the slowest reader sets the pace. With n below one, inCh is
+// drained and no channel is returned.
+func Tee[T any](ctx context.Context, inCh <-chan Result[T], n int) []<-chan Result[T] {
+	n = max(n, 0)
+	outChs := make([]chan Result[T], n)
+	readOnly := make([]<-chan Result[T], n)
+	for i := range outChs {
+		outChs[i] = make(chan Result[T])
+		readOnly[i] = outChs[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, outCh := range outChs {
+				close(outCh)
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case in, ok := <-inCh:
+				if !ok {
+					return // input channel closed, exit
+				}
+				if !broadcast(ctx, outChs, in) {
+					return
+				}
+			}
+		}
+	}()
+	return readOnly
+}
+
+// broadcast sends value to every channel of outChs, in whichever order they are ready, and reports whether it was
+// delivered to all of them before the context was done.
+func broadcast[T any](ctx context.Context, outChs []chan Result[T], value Result[T]) bool {
+	cases := make([]reflect.SelectCase, 0, len(outChs)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	for _, outCh := range outChs {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(outCh), Send: reflect.ValueOf(value)})
+	}
+
+	for remaining := len(outChs); remaining > 0; remaining-- {
+		chosen, _, _ := reflect.Select(cases)
+		if chosen == 0 {
+			return false // context done
+		}
+		cases[chosen].Chan = reflect.Value{} // a zero channel is ignored by select
+	}
+	return true
+}
+
+// Partition sends the results of inCh that match the predicate on the first channel and the others on the second.
+// Both channels must be read, and both are closed once inCh is closed or the context is done.
+func Partition[T any](ctx context.Context, inCh <-chan Result[T], predicate func(Result[T]) bool) (<-chan Result[T], <-chan Result[T]) {
+	matched := make(chan Result[T])
+	unmatched := make(chan Result[T])
+
+	go func() {
+		defer close(matched)
+		defer close(unmatched)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case in, ok := <-inCh:
+				if !ok {
+					return // input channel closed, exit
+				}
+				outCh := unmatched
+				if predicate(in) {
+					outCh = matched
+				}
+				if !send(ctx, outCh, in) {
+					return
+				}
+			}
+		}
+	}()
+	return matched, unmatched
+}
+
+// Zip pairs the n-th result of firstCh with the n-th result of secondCh. A pair fails with the errors of both results
+// joined if either of them failed. The output channel is closed once either input channel is closed, leaving the
+// remaining results of the other one unread, or once the context is done.
+func Zip[T any, U any](ctx context.Context, firstCh <-chan Result[T], secondCh <-chan Result[U]) <-chan Result[Pair[T, U]] {
+	outCh := make(chan Result[Pair[T, U]])
+
+	go func() {
+		defer close(outCh)
+		for {
+			var first Result[T]
+			var second Result[U]
+			select {
+			case <-ctx.Done():
+				return
+			case in, ok := <-firstCh:
+				if !ok {
+					return // input channel closed, exit
+				}
+				first = in
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case in, ok := <-secondCh:
+				if !ok {
+					return // input channel closed, exit
+				}
+				second = in
+			}
+
+			out := Result[Pair[T, U]]{Value: Pair[T, U]{First: first.Value, Second: second.Value}}
+			if first.Err != nil || second.Err != nil {
+				out = Result[Pair[T, U]]{Err: errors.Join(first.Err, second.Err)}
+			}
+			if !send(ctx, outCh, out) {
+				return
+			}
+		}
+	}()
+	return outCh
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/internal/leaktest"
+)
+
+// generate returns a closed channel holding the given results.
+func generate[T any](results ...Result[T]) <-chan Result[T] {
+	ch := make(chan Result[T], len(results))
+	for _, res := range results {
+		ch <- res
+	}
+	close(ch)
+	return ch
+}
+
+func values(values ...int) []Result[int] {
+	results := make([]Result[int], 0, len(values))
+	for _, value := range values {
+		results = append(results, Result[int]{Value: value})
+	}
+	return results
+}
+
+func TestMerge(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	merged := Merge(context.Background(),
+		generate(values(1, 2)...),
+		generate(Result[int]{Err: ErrAtValue3}),
+		generate[int](),
+		generate(values(4, 5, 6)...),
+	)
+
+	assert.ElementsMatch(t, append(values(1, 2, 4, 5, 6), Result[int]{Err: ErrAtValue3}), collect(merged))
+}
+
+func TestTee(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	input := append(values(1, 2, 3), Result[int]{Err: ErrAtValue3})
+	outChs := Tee(context.Background(), generate(input...), 3)
+
+	// read the channels in reverse order and at different paces, each one still gets every result in order
+	got := make([][]Result[int], len(outChs))
+	var wg sync.WaitGroup
+	for i := len(outChs) - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = collect(outChs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range got {
+		assert.Equal(t, input, got[i])
+	}
+}
+
+func TestTee_NoReaders(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	for _, n := range []int{0, -1} {
+		assert.Empty(t, Tee(context.Background(), generate(values(1, 2, 3)...), n))
+	}
+}
+
+func TestPartition(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	even, odd := Partition(context.Background(), generate(values(1, 2, 3, 4, 5)...), func(res Result[int]) bool {
+		return res.Value%2 == 0
+	})
+
+	var gotEven, gotOdd []Result[int]
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); gotEven = collect(even) }()
+	go func() { defer wg.Done(); gotOdd = collect(odd) }()
+	wg.Wait()
+
+	assert.Equal(t, values(2, 4), gotEven)
+	assert.Equal(t, values(1, 3, 5), gotOdd)
+}
+
+func TestZip(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	first := generate(Result[int]{Value: 1}, Result[int]{Err: ErrAtValue3}, Result[int]{Value: 3}, Result[int]{Value: 4})
+	second := generate(Result[string]{Value: "a"}, Result[string]{Value: "b"}, Result[string]{Value: "c"})
+
+	got := collect(Zip(context.Background(), first, second))
+
+	if assert.Len(t, got, 3, "zip should stop with the shortest input") {
+		assert.Equal(t, Result[Pair[int, string]]{Value: Pair[int, string]{First: 1, Second: "a"}}, got[0])
+		assert.ErrorIs(t, got[1].Err, ErrAtValue3)
+		assert.Equal(t, Result[Pair[int, string]]{Value: Pair[int, string]{First: 3, Second: "c"}}, got[2])
+	}
+}
+
+func TestCombinators_Cancel(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, inCh <-chan Result[int]) []<-chan Result[int]
+	}{
+		{
+			name: "Merge",
+			run: func(ctx context.Context, inCh <-chan Result[int]) []<-chan Result[int] {
+				return []<-chan Result[int]{Merge(ctx, inCh, inCh)}
+			},
+		},
+		{
+			name: "Tee",
+			run: func(ctx context.Context, inCh <-chan Result[int]) []<-chan Result[int] {
+				return Tee(ctx, inCh, 2)
+			},
+		},
+		{
+			name: "Partition",
+			run: func(ctx context.Context, inCh <-chan Result[int]) []<-chan Result[int] {
+				matched, unmatched := Partition(ctx, inCh, func(Result[int]) bool { return true })
+				return []<-chan Result[int]{matched, unmatched}
+			},
+		},
+		{
+			name: "Zip",
+			run: func(ctx context.Context, inCh <-chan Result[int]) []<-chan Result[int] {
+				zipped := Zip(ctx, inCh, inCh)
+				return []<-chan Result[int]{PipeN(context.Background(), zipped, 1, func(_ context.Context, res Result[Pair[int, int]]) Result[int] {
+					return Result[int]{Value: res.Value.First, Err: res.Err}
+				})}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer leaktest.Verify(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			inCh := make(chan Result[int]) // never closed, the combinator only ends when cancelled
+			outChs := tt.run(ctx, inCh)
+
+			// an unread result is pending when the context is cancelled
+			inCh <- Result[int]{Value: 1}
+			cancel()
+
+			for _, outCh := range outChs {
+				for range outCh {
+				}
+			}
+		})
+	}
+}
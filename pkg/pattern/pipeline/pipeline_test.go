@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/internal/leaktest"
+)
+
+var ErrAtValue3 = errors.New("error at value 3")
+
+func TestGeneratorAndProcessor(t *testing.T) {
+	tests := []struct {
+		name           string
+		max            int
+		processFunc    ProcessFunc[int, string]
+		cancel         bool // whether to cancel the context before waiting for the result
+		expectedOutput []Result[string]
+	}{
+		{
+			name: "Successful operation",
+			max:  5,
+			processFunc: func(ctx context.Context, res Result[int]) Result[string] {
+				return Result[string]{Value: "Processed: " + fmt.Sprint(res.Value)}
+			},
+			expectedOutput: []Result[string]{
+				{Value: "Processed: 0"},
+				{Value: "Processed: 1"},
+				{Value: "Processed: 2"},
+				{Value: "Processed: 3"},
+				{Value: "Processed: 4"},
+			},
+		},
+		{
+			name: "Context cancellation",
+			max:  5,
+			processFunc: func(ctx context.Context, res Result[int]) Result[string] {
+				return Result[string]{Value: "Processed: " + fmt.Sprint(res.Value)}
+			},
+			cancel:         true,
+			expectedOutput: nil, // No output expected due to context cancellation.
+		},
+		{
+			name: "Processing error",
+			max:  5,
+			processFunc: func(ctx context.Context, res Result[int]) Result[string] {
+				if res.Value == 3 {
+					return Result[string]{Err: ErrAtValue3}
+				}
+				return Result[string]{Value: "Processed: " + fmt.Sprint(res.Value)}
+			},
+			expectedOutput: []Result[string]{
+				{Value: "Processed: 0"},
+				{Value: "Processed: 1"},
+				{Value: "Processed: 2"},
+				{Err: ErrAtValue3},
+				{Value: "Processed: 4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.cancel {
+				cancel() // cancel the context before waiting for the result
+			}
+
+			inputCh := make(chan Result[int])
+			go func(n int) { // tt is shared by the loop iterations, the producer may outlive this one
+				defer close(inputCh)
+				for i := 0; i < n; i++ {
+					select {
+					case inputCh <- Result[int]{Value: i}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}(tt.max)
+			procCh := Pipe(ctx, inputCh, tt.processFunc)
+
+			var gotResults []Result[string]
+			for res := range procCh {
+				gotResults = append(gotResults, res)
+			}
+
+			assert.ElementsMatch(t, tt.expectedOutput, gotResults)
+		})
+	}
+}
+
+func TestPipeN(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		opts    []StageOption
+		ordered bool
+	}{
+		{name: "Single worker", workers: 1, ordered: true},
+		{name: "Unordered", workers: 4},
+		{name: "Ordered", workers: 4, opts: []StageOption{WithOrder()}, ordered: true},
+		{name: "Ordered with buffer", workers: 4, opts: []StageOption{WithOrder(), WithBuffer(8)}, ordered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer leaktest.Verify(t)
+
+			inputCh := make(chan Result[int])
+			go func() {
+				defer close(inputCh)
+				for i := 0; i < 20; i++ {
+					inputCh <- Result[int]{Value: i}
+				}
+			}()
+
+			var want, got []Result[int]
+			for i := 0; i < 20; i++ {
+				want = append(want, Result[int]{Value: i * i})
+			}
+			for res := range PipeN(context.Background(), inputCh, tt.workers, slowSquare, tt.opts...) {
+				got = append(got, res)
+			}
+
+			if tt.ordered {
+				assert.Equal(t, want, got)
+			} else {
+				assert.ElementsMatch(t, want, got)
+			}
+		})
+	}
+}
+
+func TestPipeN_ScalesSlowStage(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	const items, workers = 8, 4
+	var active, peak atomic.Int32
+	release := make(chan struct{})
+	slow := func(ctx context.Context, res Result[int]) Result[int] {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		return res
+	}
+
+	inputCh := make(chan Result[int], items)
+	for i := 0; i < items; i++ {
+		inputCh <- Result[int]{Value: i}
+	}
+	close(inputCh)
+
+	outCh := PipeN(context.Background(), inputCh, workers, slow, WithOrder())
+	// Every worker should pick up an item while the first one is still being processed.
+	assert.Eventually(t, func() bool { return active.Load() == workers }, time.Second, time.Millisecond,
+		"workers should process items concurrently")
+	close(release)
+
+	count := 0
+	for range outCh {
+		count++
+	}
+	assert.Equal(t, items, count)
+	assert.Equal(t, int32(workers), peak.Load(), "no more than n items should be processed at once")
+}
+
+func TestPipeN_DoesNotWriteIntoOptions(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	opts := make([]StageOption, 1, 2)
+	opts[0] = WithOrder()
+	spare := opts[:2]
+	spare[1] = WithBuffer(1)
+
+	inputCh := make(chan Result[int])
+	close(inputCh)
+	for range PipeN(context.Background(), inputCh, 2, slowSquare, opts...) {
+	}
+	var config stageConfig
+	spare[1](&config)
+	assert.Equal(t, 1, config.buffer)
+	assert.Zero(t, config.workers, "the caller's backing array should be left untouched")
+}
+
+func TestPipeN_Cancel(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inputCh := make(chan Result[int]) // never closed, the stage only ends when cancelled
+	outCh := PipeN(ctx, inputCh, 4, slowSquare, WithOrder())
+
+	inputCh <- Result[int]{Value: 2}
+	assert.Equal(t, Result[int]{Value: 4}, <-outCh)
+
+	cancel()
+	for range outCh {
+	}
+}
@@ -0,0 +1,31 @@
+package pipeline
+
+// ErrorPolicy decides what a pipeline does with the results a stage returns with an error.
+type ErrorPolicy int
+
+const (
+	// Propagate sends failed results downstream as Result.Err, and each stage decides what to do with them.
+	Propagate ErrorPolicy = iota
+	// FailFast stops the whole pipeline on the first error, Wait then returns it.
+	FailFast
+	// Skip drops failed results and counts them.
+	Skip
+	// Collect drops failed results and lets the pipeline finish, Wait then returns all the errors joined.
+	Collect
+)
+
+// String returns the name of the policy.
+func (p ErrorPolicy) String() string {
+	switch p {
+	case Propagate:
+		return "propagate"
+	case FailFast:
+		return "fail-fast"
+	case Skip:
+		return "skip"
+	case Collect:
+		return "collect"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern/internal/leaktest"
+)
+
+var ErrEvenValue = errors.New("even value")
+
+// failEven fails on even values.
+func failEven(_ context.Context, res Result[int]) Result[int] {
+	if res.Value%2 == 0 {
+		return Result[int]{Err: fmt.Errorf("value %d: %w", res.Value, ErrEvenValue)}
+	}
+	return res
+}
+
+func TestPipeline_ErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      ErrorPolicy
+		stageOpts   []StageOption
+		wantResults int
+		wantErrors  int // number of results with an error
+		wantSkipped int64
+		wantErr     int // number of errors joined in the Wait error
+	}{
+		{name: "Propagate", policy: Propagate, wantResults: 6, wantErrors: 3},
+		{name: "Skip", policy: Skip, wantResults: 3, wantSkipped: 3},
+		{name: "Collect", policy: Collect, wantResults: 3, wantErr: 3},
+		{name: "Stage overrides pipeline policy", policy: FailFast, stageOpts: []StageOption{WithStageErrorPolicy(Skip)}, wantResults: 3, wantSkipped: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer leaktest.Verify(t)
+
+			p := New(context.Background(), WithErrorPolicy(tt.policy))
+			checked := Then(FromValues(p, 1, 2, 3, 4, 5, 6), failEven, append(tt.stageOpts, WithWorkers(2))...)
+
+			results, errs := 0, 0
+			for res := range checked.Out() {
+				results++
+				if res.Err != nil {
+					assert.ErrorIs(t, res.Err, ErrEvenValue)
+					errs++
+				}
+			}
+			err := p.Wait()
+
+			assert.Equal(t, tt.wantResults, results)
+			assert.Equal(t, tt.wantErrors, errs)
+			assert.Equal(t, tt.wantSkipped, checked.Skipped())
+			assert.Equal(t, tt.wantSkipped, p.Skipped())
+			if tt.wantErr == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrEvenValue)
+			assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), tt.wantErr)
+		})
+	}
+}
+
+func TestPipeline_FailFast(t *testing.T) {
+	defer leaktest.Verify(t)
+
+	inputCh := make(chan Result[int]) // never closed, only the failure ends the pipeline
+	p := New(context.Background(), WithErrorPolicy(FailFast))
+	formatted := Then(Then(From(p, inputCh), failEven, WithWorkers(2), WithOrder()), format)
+
+	go func() {
+		for i := 1; ; i++ {
+			if !send(p.Context(), inputCh, Result[int]{Value: i}) {
+				return
+			}
+		}
+	}()
+
+	var got []Result[string]
+	for res := range formatted.Out() {
+		got = append(got, res)
+	}
+
+	assert.ErrorIs(t, p.Wait(), ErrEvenValue)
+	assert.NotContains(t, got, Result[string]{Value: "2"})
+	for _, res := range got {
+		assert.NoError(t, res.Err, "failed results should not travel downstream")
+	}
+}
+
+func TestErrorPolicy_String(t *testing.T) {
+	assert.Equal(t, "fail-fast", FailFast.String())
+	assert.Equal(t, "unknown", ErrorPolicy(42).String())
+}
@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// stageConfig holds the configuration of a single stage.
+type stageConfig struct {
+	workers int  // workers is the number of goroutines processing items, minimum one.
+	buffer  int  // buffer is the capacity of the stage output channel.
+	ordered bool // ordered emits results in input order when the stage has several workers.
+
+	policy *ErrorPolicy // policy, if set, overrides the pipeline error policy.
+
+	// keep, if set, is called with the error of every failed result and reports whether to send it downstream.
+	keep func(err error) bool
+
+	wg *sync.WaitGroup // wg, if set, is released once the stage output channel is closed.
+}
+
+// StageOption configures a pipeline stage.
+type StageOption func(*stageConfig)
+
+// WithWorkers runs the stage on n goroutines.
+func WithWorkers(n int) StageOption {
+	return func(c *stageConfig) {
+		c.workers = n
+	}
+}
+
+// WithBuffer sets the capacity of the stage output channel.
+func WithBuffer(n int) StageOption {
+	return func(c *stageConfig) {
+		c.buffer = n
+	}
+}
+
+// WithStageErrorPolicy overrides the pipeline error policy for the errors returned by this stage.
+func WithStageErrorPolicy(policy ErrorPolicy) StageOption {
+	return func(c *stageConfig) {
+		c.policy = &policy
+	}
+}
+
+// WithOrder makes a stage with several workers emit its results in input order.
+func WithOrder() StageOption {
+	return func(c *stageConfig) {
+		c.ordered = true
+	}
+}
+
+func newStageConfig(opts ...StageOption) stageConfig {
+	config := stageConfig{workers: 1}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	config.workers = max(config.workers, 1)
+	config.buffer = max(config.buffer, 0)
+	return config
+}
+
+// runStage processes inCh with processFunc according to config. Unlike Pipe, every send is guarded by the context,
+// so once it is done all the stage goroutines exit and the output channel is closed.
+func runStage[T any, U any](ctx context.Context, inCh <-chan Result[T], processFunc ProcessFunc[T, U], config stageConfig) <-chan Result[U] {
+	outCh := make(chan Result[U], config.buffer)
+
+	var wg sync.WaitGroup
+	if config.ordered && config.workers > 1 {
+		runOrdered(ctx, &wg, inCh, outCh, processFunc, config)
+	} else {
+		runUnordered(ctx, &wg, inCh, outCh, processFunc, config)
+	}
+
+	go func() {
+		wg.Wait()
+		close(outCh)
+		if config.wg != nil {
+			config.wg.Done()
+		}
+	}()
+	return outCh
+}
+
+// runUnordered starts workers that each read from inCh and send to outCh.
+func runUnordered[T any, U any](ctx context.Context, wg *sync.WaitGroup, inCh <-chan Result[T], outCh chan<- Result[U], processFunc ProcessFunc[T, U], config stageConfig) {
+	for i := 0; i < config.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case in, ok := <-inCh:
+					if !ok {
+						return // input channel closed, exit worker
+					}
+					if out := processFunc(ctx, in); config.forward(out.Err) && !send(ctx, outCh, out) {
+						return
+					}
+				}
+			}
+		}()
+	}
+}
+
+// runOrdered starts a dispatcher, workers and a collector. The dispatcher queues a result slot per item, in input
+// order, before handing the item to a worker; the collector emits the slots in queue order. The queue capacity bounds
+// how far the workers can get ahead of the slowest item.
+func runOrdered[T any, U any](ctx context.Context, wg *sync.WaitGroup, inCh <-chan Result[T], outCh chan<- Result[U], processFunc ProcessFunc[T, U], config stageConfig) {
+	type task struct {
+		in   Result[T]
+		slot chan Result[U]
+	}
+	tasks := make(chan task)
+	queue := make(chan chan Result[U], config.workers)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(queue)
+		defer close(tasks)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case in, ok := <-inCh:
+				if !ok {
+					return // input channel closed, exit dispatcher
+				}
+				slot := make(chan Result[U], 1) // buffered so that workers never wait for the collector
+				if !send(ctx, queue, slot) || !send(ctx, tasks, task{in: in, slot: slot}) {
+					return
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < config.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				t.slot <- processFunc(ctx, t.in)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for slot := range queue {
+			select {
+			case <-ctx.Done():
+				return
+			case out := <-slot:
+				if config.forward(out.Err) && !send(ctx, outCh, out) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// forward reports whether a result with the given error should be sent downstream.
+func (c stageConfig) forward(err error) bool {
+	return err == nil || c.keep == nil || c.keep(err)
+}
+
+// send sends value on ch, or returns false once the context is done.
+func send[T any](ctx context.Context, ch chan<- T, value T) bool {
+	select {
+	case ch <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
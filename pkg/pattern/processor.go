@@ -0,0 +1,124 @@
+package pattern
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Options holds the processing options shared by the pools, for jobs of type T.
+type Options[T any] struct {
+	retry      *RetryPolicy
+	gate       func(context.Context, Job[T]) error
+	deadLetter chan<- DeadLetter[T]
+	window     int
+}
+
+// Option configures how a pool processes jobs of type T. The job type of options that do not depend on it, such as
+// WithRetry, must be given explicitly: pattern.WithRetry[int](policy).
+type Option[T any] func(*Options[T])
+
+// WithRetry retries failed jobs according to the given policy.
+func WithRetry[T any](policy RetryPolicy) Option[T] {
+	return func(o *Options[T]) {
+		o.retry = &policy
+	}
+}
+
+// WithRetryGate makes every retry wait for gate before it runs, such as for a rate limiter token, so that retries
+// are throttled like first attempts. The job stops retrying, with the error of its last attempt, if gate fails.
+func WithRetryGate[T any](gate func(context.Context, Job[T]) error) Option[T] {
+	return func(o *Options[T]) {
+		o.gate = gate
+	}
+}
+
+// WithOrderedResults emits results in submission order rather than completion order.
+// At most window jobs are processed or waiting to be emitted at any time, see Sequencer.
+func WithOrderedResults[T any](window int) Option[T] {
+	return func(o *Options[T]) {
+		o.window = max(window, 1)
+	}
+}
+
+// Processor applies the pool options around a ProcessFunc.
+type Processor[T any, U any] struct {
+	processFunc ProcessFunc[T, U]
+	retry       *RetryPolicy
+	gate        func(context.Context, Job[T]) error
+	deadLetter  chan<- DeadLetter[T]
+	window      int
+}
+
+// NewProcessor creates a Processor running processFunc with the given options.
+func NewProcessor[T any, U any](processFunc ProcessFunc[T, U], opts ...Option[T]) *Processor[T, U] {
+	var options Options[T]
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Processor[T, U]{
+		processFunc: processFunc,
+		retry:       options.retry,
+		gate:        options.gate,
+		deadLetter:  options.deadLetter,
+		window:      options.window,
+	}
+}
+
+// Sequencer returns a Sequencer writing to results if ordered results were requested, nil otherwise.
+func (p *Processor[T, U]) Sequencer(results chan<- Result[T, U]) *Sequencer[T, U] {
+	if p.window == 0 {
+		return nil
+	}
+	return NewSequencer(p.window, results)
+}
+
+// Process runs the job, retrying it if a retry policy is set, and returns its final Result.
+// It returns false if the job permanently failed and was sent to the dead-letter sink instead. Sending to the sink
+// blocks the calling worker until the sink has room or ctx is done, so a full sink holds up the pool.
+func (p *Processor[T, U]) Process(ctx context.Context, job Job[T]) (Result[T, U], bool) {
+	result := Result[T, U]{Job: job}
+	firstAttemptAt := time.Now()
+
+	var errs []error
+	for {
+		result.Attempts++
+		result.Value, result.Err = p.processFunc(ctx, job.Value)
+		if result.Err == nil {
+			return result, true
+		}
+		errs = append(errs, result.Err)
+		if !p.retryable(ctx, job, result) {
+			break
+		}
+	}
+
+	if p.deadLetter == nil || ctx.Err() != nil {
+		return result, true
+	}
+
+	letter := DeadLetter[T]{
+		Job:            job,
+		Err:            errors.Join(errs...),
+		Attempts:       result.Attempts,
+		FirstAttemptAt: firstAttemptAt,
+		FailedAt:       time.Now(),
+	}
+	select {
+	case <-ctx.Done():
+		return result, true // the sink is not keeping up, deliver the failure as a result instead.
+	case p.deadLetter <- letter:
+		return result, false
+	}
+}
+
+// retryable reports whether the failed result should be attempted again, waiting for the backoff and the gate if so.
+func (p *Processor[T, U]) retryable(ctx context.Context, job Job[T], result Result[T, U]) bool {
+	if p.retry == nil || result.Attempts >= p.retry.MaxAttempts || !p.retry.shouldRetry(result.Err) {
+		return false
+	}
+	if !p.retry.wait(ctx, result.Attempts) {
+		return false
+	}
+	return p.gate == nil || p.gate(ctx, job) == nil
+}
@@ -0,0 +1,150 @@
+package pattern
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ErrTransient = errors.New("transient error")
+	ErrPermanent = errors.New("permanent error")
+)
+
+// failingFunc returns a ProcessFunc failing with err for the first failures calls and doubling the value afterwards.
+func failingFunc(failures int, err error) ProcessFunc[int, int] {
+	calls := 0
+	return func(_ context.Context, value int) (int, error) {
+		calls++
+		if calls <= failures {
+			return 0, err
+		}
+		return value * 2, nil
+	}
+}
+
+func TestProcessor_Process(t *testing.T) {
+	job := Job[int]{ID: 1, Value: 21}
+	fastRetry := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	tests := []struct {
+		name        string
+		processFunc ProcessFunc[int, int]
+		opts        []Option[int]
+		timeout     time.Duration // context timeout, zero means none
+		want        Result[int, int]
+	}{
+		{
+			name:        "No retry policy",
+			processFunc: failingFunc(1, ErrTransient),
+			want:        Result[int, int]{Job: job, Err: ErrTransient, Attempts: 1},
+		},
+		{
+			name:        "Succeeds after retries",
+			processFunc: failingFunc(2, ErrTransient),
+			opts:        []Option[int]{WithRetry[int](fastRetry)},
+			want:        Result[int, int]{Job: job, Value: 42, Attempts: 3},
+		},
+		{
+			name:        "Exhausts attempts",
+			processFunc: failingFunc(5, ErrTransient),
+			opts:        []Option[int]{WithRetry[int](fastRetry)},
+			want:        Result[int, int]{Job: job, Err: ErrTransient, Attempts: 3},
+		},
+		{
+			name:        "Retry predicate rejects error",
+			processFunc: failingFunc(1, ErrPermanent),
+			opts: []Option[int]{WithRetry[int](RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				RetryIf:        func(err error) bool { return errors.Is(err, ErrTransient) },
+			})},
+			want: Result[int, int]{Job: job, Err: ErrPermanent, Attempts: 1},
+		},
+		{
+			name:        "Context errors are not retried",
+			processFunc: failingFunc(1, context.Canceled),
+			opts:        []Option[int]{WithRetry[int](fastRetry)},
+			want:        Result[int, int]{Job: job, Err: context.Canceled, Attempts: 1},
+		},
+		{
+			name:        "Backoff past the context deadline",
+			processFunc: failingFunc(5, ErrTransient),
+			opts:        []Option[int]{WithRetry[int](RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second})},
+			timeout:     100 * time.Millisecond,
+			want:        Result[int, int]{Job: job, Err: ErrTransient, Attempts: 1},
+		},
+		{
+			name:        "Gate fails",
+			processFunc: failingFunc(5, ErrTransient),
+			opts: []Option[int]{
+				WithRetry[int](fastRetry),
+				WithRetryGate(func(context.Context, Job[int]) error { return ErrPermanent }),
+			},
+			want: Result[int, int]{Job: job, Err: ErrTransient, Attempts: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+			}
+			defer cancel() // ensure resources are cleaned up
+
+			got, _ := NewProcessor(tt.processFunc, tt.opts...).Process(ctx, job)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProcessor_RetryGate(t *testing.T) {
+	var gated []Job[int]
+	processor := NewProcessor(failingFunc(2, ErrTransient),
+		WithRetry[int](RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithRetryGate(func(_ context.Context, job Job[int]) error {
+			gated = append(gated, job)
+			return nil
+		}),
+	)
+
+	job := Job[int]{ID: 1, Value: 21}
+	result, _ := processor.Process(context.Background(), job)
+	assert.Equal(t, Result[int, int]{Job: job, Value: 42, Attempts: 3}, result)
+	assert.Equal(t, []Job[int]{job, job}, gated, "every retry should go through the gate")
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{name: "First retry", policy: RetryPolicy{InitialBackoff: 10 * time.Millisecond}, attempt: 1, want: 10 * time.Millisecond},
+		{name: "Default multiplier", policy: RetryPolicy{InitialBackoff: 10 * time.Millisecond}, attempt: 3, want: 40 * time.Millisecond},
+		{name: "Custom multiplier", policy: RetryPolicy{InitialBackoff: 10 * time.Millisecond, Multiplier: 3}, attempt: 3, want: 90 * time.Millisecond},
+		{name: "Capped", policy: RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 25 * time.Millisecond}, attempt: 10, want: 25 * time.Millisecond},
+		{name: "Uncapped does not overflow", policy: RetryPolicy{InitialBackoff: time.Second}, attempt: 10000, want: math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.Backoff(tt.attempt))
+		})
+	}
+}
+
+func TestRetryPolicy_BackoffJitter(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		got := policy.Backoff(1)
+		assert.GreaterOrEqual(t, got, 50*time.Millisecond)
+		assert.LessOrEqual(t, got, 100*time.Millisecond)
+	}
+}
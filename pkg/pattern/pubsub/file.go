@@ -0,0 +1,370 @@
+package pubsub
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	logExt      = ".log"
+	offsetsFile = "offsets.json"
+
+	// compactMin is the number of obsolete lines a file holds at least before it is compacted.
+	compactMin = 64
+)
+
+// FileStorage is a Storage that survives a restart. Each topic log is a file of JSON lines in a directory, starting
+// with a header that holds the offset of its first message, and the acknowledged offsets are appended as JSON lines
+// to a file next to them. The messages are also kept in memory, so reads never touch the disk: every retained
+// message is held in memory, which is unbounded with a zero RetentionPolicy.
+//
+// Trims and commits are appended to the files, which are only rewritten once most of their lines are obsolete, so
+// that both cost the same as an append. Appends are written through but not synced, so a message may be lost if the
+// machine, not the process, crashes. A line torn by a crash is truncated when the storage is opened again.
+type FileStorage[T any] struct {
+	dir     string
+	memory  *MemoryStorage[T]
+	files   map[string]*os.File // files holds the open topic logs, guarded by memory.mu.
+	trimmed map[string]int      // trimmed counts the lines of each topic log holding trimmed messages or markers.
+	offsets *os.File            // offsets is the offsets file opened for appending, nil until the first commit.
+	commits int                 // commits counts the lines of the offsets file.
+}
+
+// logHeader is the first line of a topic log.
+type logHeader struct {
+	Topic string `json:"topic"`
+	First int64  `json:"first"`
+}
+
+// logRecord is a line of a topic log after its header: a message, or a marker trimming the messages before Trim.
+type logRecord[T any] struct {
+	Message[T]
+	Trim *int64 `json:"trim,omitempty"`
+}
+
+// trimMarker is the line appended to a topic log when it is trimmed.
+type trimMarker struct {
+	Trim int64 `json:"trim"`
+}
+
+// committedOffset is a line of the offsets file, the last line of a consumer holds its offset.
+type committedOffset struct {
+	consumer
+	Offset int64 `json:"offset"`
+}
+
+// OpenFileStorage opens the storage kept in dir, creating dir if needed, and loads the messages and offsets already
+// there.
+func OpenFileStorage[T any](dir string) (*FileStorage[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &FileStorage[T]{dir: dir, memory: NewMemoryStorage[T](), files: map[string]*os.File{}, trimmed: map[string]int{}}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStorage[T]) Append(topic string, value T, at time.Time) (Message[T], error) {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	file, err := s.file(topic)
+	if err != nil {
+		return Message[T]{}, err
+	}
+	log := s.memory.log(topic)
+	message := Message[T]{Topic: topic, Offset: log.first + int64(len(log.messages)), Time: at, Value: value}
+	if err := json.NewEncoder(file).Encode(message); err != nil {
+		return Message[T]{}, fmt.Errorf("pubsub: append to %q: %w", topic, err)
+	}
+	log.messages = append(log.messages, message)
+	return message, nil
+}
+
+func (s *FileStorage[T]) Read(topic string, offset int64, limit int) ([]Message[T], error) {
+	return s.memory.Read(topic, offset, limit)
+}
+
+func (s *FileStorage[T]) Bounds(topic string) (first, next int64, err error) {
+	return s.memory.Bounds(topic)
+}
+
+// Trim removes the messages of topic before offset. It appends a marker to the log file, and rewrites the file once
+// most of its lines are trimmed.
+func (s *FileStorage[T]) Trim(topic string, offset int64) error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	log, ok := s.memory.logs[topic]
+	if !ok {
+		return nil
+	}
+	first := log.first
+	if !s.memory.trim(topic, offset) {
+		return nil
+	}
+	s.trimmed[topic] += int(log.first - first)
+
+	if s.trimmed[topic] < max(len(log.messages), compactMin) {
+		file, err := s.file(topic)
+		if err == nil {
+			err = json.NewEncoder(file).Encode(trimMarker{Trim: log.first})
+		}
+		if err != nil {
+			return fmt.Errorf("pubsub: trim %q: %w", topic, err)
+		}
+		s.trimmed[topic]++
+		return nil
+	}
+
+	if file, ok := s.files[topic]; ok {
+		delete(s.files, topic)
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	err := writeFile(s.logPath(topic), func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		if err := encoder.Encode(logHeader{Topic: topic, First: log.first}); err != nil {
+			return err
+		}
+		for _, message := range log.messages {
+			if err := encoder.Encode(message); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("pubsub: trim %q: %w", topic, err)
+	}
+	s.trimmed[topic] = 0
+	return nil
+}
+
+// Commit records offset as acknowledged by the named subscriber of topic. It appends it to the offsets file, and
+// rewrites the file once most of its lines are outdated.
+func (s *FileStorage[T]) Commit(name, topic string, offset int64) error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	c := consumer{Name: name, Topic: topic}
+	s.memory.offsets[c] = offset
+	err := s.appendOffset(committedOffset{consumer: c, Offset: offset})
+	if err != nil {
+		return fmt.Errorf("pubsub: commit %q on %q: %w", name, topic, err)
+	}
+	return nil
+}
+
+// appendOffset appends a line to the offsets file, or rewrites it with the current offsets if most lines are
+// outdated. It must be called with memory.mu held.
+func (s *FileStorage[T]) appendOffset(committed committedOffset) error {
+	path := filepath.Join(s.dir, offsetsFile)
+	if s.commits < max(2*len(s.memory.offsets), compactMin) {
+		if s.offsets == nil {
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+			if err != nil {
+				return err
+			}
+			s.offsets = file
+		}
+		if err := json.NewEncoder(s.offsets).Encode(committed); err != nil {
+			return err
+		}
+		s.commits++
+		return nil
+	}
+
+	if s.offsets != nil {
+		file := s.offsets
+		s.offsets = nil
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	err := writeFile(path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for c, o := range s.memory.offsets {
+			if err := encoder.Encode(committedOffset{consumer: c, Offset: o}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.commits = len(s.memory.offsets)
+	return nil
+}
+
+func (s *FileStorage[T]) Committed(name, topic string) (offset int64, ok bool, err error) {
+	return s.memory.Committed(name, topic)
+}
+
+// Close closes the open log files. The storage must not be used afterwards.
+func (s *FileStorage[T]) Close() error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	var errs []error
+	for topic, file := range s.files {
+		errs = append(errs, file.Close())
+		delete(s.files, topic)
+	}
+	if s.offsets != nil {
+		errs = append(errs, s.offsets.Close())
+		s.offsets = nil
+	}
+	return errors.Join(errs...)
+}
+
+// file returns the log file of topic opened for appending, creating it with its header if needed. It must be called
+// with memory.mu held.
+func (s *FileStorage[T]) file(topic string) (*os.File, error) {
+	if file, ok := s.files[topic]; ok {
+		return file, nil
+	}
+	path := s.logPath(topic)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		first := s.memory.log(topic).first
+		err := writeFile(path, func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(logHeader{Topic: topic, First: first})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.files[topic] = file
+	return file, nil
+}
+
+func (s *FileStorage[T]) logPath(topic string) string {
+	return filepath.Join(s.dir, url.PathEscape(topic)+logExt)
+}
+
+// load reads the topic logs and the offsets file of the storage directory.
+func (s *FileStorage[T]) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), logExt) {
+			continue
+		}
+		if err := s.loadLog(filepath.Join(s.dir, entry.Name())); err != nil {
+			return fmt.Errorf("pubsub: load %s: %w", entry.Name(), err)
+		}
+	}
+
+	err = readLines(filepath.Join(s.dir, offsetsFile), func(line []byte) error {
+		var committed committedOffset
+		if err := json.Unmarshal(line, &committed); err != nil {
+			return err
+		}
+		s.memory.offsets[committed.consumer] = committed.Offset
+		s.commits++
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("pubsub: load %s: %w", offsetsFile, err)
+	}
+	return nil
+}
+
+// loadLog reads a topic log, applying its trim markers.
+func (s *FileStorage[T]) loadLog(path string) error {
+	var topic string
+	var log *memoryLog[T]
+	return readLines(path, func(line []byte) error {
+		if log == nil {
+			var header logHeader
+			if err := json.Unmarshal(line, &header); err != nil {
+				return err
+			}
+			topic = header.Topic
+			log = s.memory.log(topic)
+			log.first = header.First
+			return nil
+		}
+
+		var record logRecord[T]
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		if record.Trim != nil {
+			first := log.first
+			s.memory.trim(topic, *record.Trim)
+			s.trimmed[topic] += int(log.first-first) + 1
+			return nil
+		}
+		log.messages = append(log.messages, record.Message)
+		return nil
+	})
+}
+
+// readLines calls fn with every line of the file at path. A last line without a newline was torn by a crash while
+// it was written, the file is truncated before it.
+func readLines(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	size := int64(0) // size is the length of the complete lines read so far.
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return os.Truncate(path, size)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+		size += int64(len(line))
+	}
+}
+
+// writeFile replaces the file at path with what write writes, so that it is never left half written.
+func writeFile(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
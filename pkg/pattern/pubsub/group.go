@@ -0,0 +1,315 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLeftGroup is returned by Receive once the member has left its group.
+	ErrLeftGroup = errors.New("pubsub: member left the group")
+	// ErrAckExpired is returned by Ack when the delivery was not acknowledged in time and was handed out again.
+	ErrAckExpired = errors.New("pubsub: delivery expired before it was acknowledged")
+	// ErrInvalidDelivery is returned by Ack for a delivery that was not returned by Receive.
+	ErrInvalidDelivery = errors.New("pubsub: delivery was not received from a group")
+	// ErrGroupConflict is returned by JoinGroup when the options differ from the ones the group was created with.
+	ErrGroupConflict = errors.New("pubsub: group options conflict with the existing group")
+	// ErrGroupNotFound is returned by DeleteGroup when there is no such group.
+	ErrGroupNotFound = errors.New("pubsub: group not found")
+	// ErrGroupHasMembers is returned by DeleteGroup while the group still has members.
+	ErrGroupHasMembers = errors.New("pubsub: group still has members")
+)
+
+// defaultAckTimeout is how long a member has to acknowledge a delivery when no ack timeout is given.
+const defaultAckTimeout = 30 * time.Second
+
+// GroupOption configures a consumer group. It applies when the first member joins, the following members must pass
+// the same options or none.
+type GroupOption func(*groupConfig)
+
+type groupConfig struct {
+	ackTimeout time.Duration
+	maxQueued  int
+}
+
+// WithAckTimeout sets how long a member has to acknowledge a delivery before it is redelivered.
+func WithAckTimeout(timeout time.Duration) GroupOption {
+	return func(c *groupConfig) {
+		c.ackTimeout = timeout
+	}
+}
+
+// WithMaxQueued bounds the messages waiting for a member, the newest are dropped beyond it. Zero means no bound.
+func WithMaxQueued(n int) GroupOption {
+	return func(c *groupConfig) {
+		c.maxQueued = n
+	}
+}
+
+// Group is a consumer group: its members share the messages published on its topic, each message going to a single
+// member, while every group and every plain subscriber still gets its own copy.
+//
+// Members pull the messages with Receive, so the load is balanced by how fast each member is, and a member joining
+// takes its share as soon as it receives. A delivery that is not acknowledged within the ack timeout is handed out
+// again, as are the unacknowledged deliveries of a member that leaves: delivery is at least once.
+//
+// The group outlives its members: once the last one leaves, the messages keep being queued, up to WithMaxQueued, for
+// the next member to join. A group that is no longer needed must be removed with DeleteGroup, which drops its queue.
+type Group[T any] struct {
+	name   string
+	topic  string
+	config groupConfig
+
+	mu       sync.Mutex
+	members  int
+	queue    []*pending[T]         // queue holds the messages waiting for a member, redeliveries first.
+	inFlight map[int64]*pending[T] // inFlight holds the messages delivered and not acknowledged yet, by id.
+	nextID   int64
+	changed  chan struct{} // changed is closed and replaced when a message is queued or a member leaves.
+}
+
+// pending is a message of the group and its current delivery.
+type pending[T any] struct {
+	id       int64
+	result   Result[T]
+	attempt  int
+	member   *Member[T]
+	deadline time.Time
+}
+
+// Member is a member of a consumer group.
+type Member[T any] struct {
+	group *Group[T]
+	left  bool // left is guarded by group.mu.
+}
+
+// Delivery is a message handed to a member, to acknowledge with Ack once processed.
+type Delivery[T any] struct {
+	Result[T]
+	Attempt int // Attempt counts the deliveries of the message, from 1.
+
+	id     int64
+	member *Member[T]
+}
+
+// JoinGroup adds a member to the named consumer group of topic, which may contain wildcards, creating the group if
+// needed. It returns ErrGroupConflict if the group exists with other options.
+func (ps *PubSub[T]) JoinGroup(name, topic string, opts ...GroupOption) (*Member[T], error) {
+	config := newGroupConfig(opts...)
+	member := &Member[T]{}
+	var err error
+	ps.update(func(subscribers registry[T]) {
+		member.group = subscribers.group(name, topic)
+		if member.group == nil {
+			member.group = newGroup[T](name, topic, config)
+			// Copy the slice as well, it may be read by a concurrent Publish.
+			subscribers.groups[topic] = append(append([]*Group[T]{}, subscribers.groups[topic]...), member.group)
+		} else if len(opts) > 0 && config != member.group.config {
+			err = ErrGroupConflict
+			return
+		}
+		member.group.mu.Lock()
+		member.group.members++
+		member.group.mu.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// DeleteGroup removes the named consumer group of topic and drops its queued messages. It returns ErrGroupNotFound if
+// there is no such group, and ErrGroupHasMembers until all its members have left.
+func (ps *PubSub[T]) DeleteGroup(name, topic string) error {
+	var err error
+	ps.update(func(subscribers registry[T]) {
+		g := subscribers.group(name, topic)
+		if g == nil {
+			err = ErrGroupNotFound
+			return
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if g.members > 0 {
+			err = ErrGroupHasMembers
+			return
+		}
+		ps.dropped.Add(int64(len(g.queue) + len(g.inFlight)))
+		g.queue, g.inFlight = nil, map[int64]*pending[T]{}
+
+		groups := make([]*Group[T], 0, len(subscribers.groups[topic]))
+		for _, group := range subscribers.groups[topic] {
+			if group != g {
+				groups = append(groups, group)
+			}
+		}
+		if len(groups) == 0 {
+			delete(subscribers.groups, topic)
+		} else {
+			subscribers.groups[topic] = groups
+		}
+	})
+	return err
+}
+
+func newGroupConfig(opts ...GroupOption) groupConfig {
+	config := groupConfig{ackTimeout: defaultAckTimeout}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+func newGroup[T any](name, topic string, config groupConfig) *Group[T] {
+	return &Group[T]{
+		name:     name,
+		topic:    topic,
+		config:   config,
+		inFlight: map[int64]*pending[T]{},
+		changed:  make(chan struct{}),
+	}
+}
+
+// Name returns the name of the group.
+func (g *Group[T]) Name() string {
+	return g.name
+}
+
+// enqueue queues result for a member and reports whether it was dropped because the queue is full.
+func (g *Group[T]) enqueue(result Result[T]) (dropped bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.config.maxQueued > 0 && len(g.queue) >= g.config.maxQueued {
+		return true
+	}
+	g.queue = append(g.queue, &pending[T]{id: g.nextID, result: result})
+	g.nextID++
+	g.signal()
+	return false
+}
+
+// requeue puts the deliveries of member back at the front of the queue, or all the expired ones if member is nil. It
+// must be called with mu held.
+func (g *Group[T]) requeue(member *Member[T], now time.Time) {
+	var redeliver []*pending[T]
+	for id, p := range g.inFlight {
+		if p.member == member || (member == nil && !now.Before(p.deadline)) {
+			delete(g.inFlight, id)
+			p.member = nil
+			redeliver = append(redeliver, p)
+		}
+	}
+	if len(redeliver) == 0 {
+		return
+	}
+	// Keep the publication order among the redeliveries.
+	sort.Slice(redeliver, func(i, j int) bool { return redeliver[i].id < redeliver[j].id })
+	g.queue = append(redeliver, g.queue...)
+	g.signal()
+}
+
+// nextDeadline returns the earliest ack deadline of the deliveries in flight, if any. It must be called with mu held.
+func (g *Group[T]) nextDeadline() (time.Time, bool) {
+	var next time.Time
+	for _, p := range g.inFlight {
+		if next.IsZero() || p.deadline.Before(next) {
+			next = p.deadline
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// signal wakes up the members waiting for a message. It must be called with mu held.
+func (g *Group[T]) signal() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// Group returns the group of the member.
+func (m *Member[T]) Group() *Group[T] {
+	return m.group
+}
+
+// Receive waits for the next message of the group, including the ones whose delivery expired, until ctx is done.
+func (m *Member[T]) Receive(ctx context.Context) (Delivery[T], error) {
+	g := m.group
+	for {
+		g.mu.Lock()
+		if m.left {
+			g.mu.Unlock()
+			return Delivery[T]{}, ErrLeftGroup
+		}
+		now := time.Now()
+		g.requeue(nil, now)
+		if len(g.queue) > 0 {
+			p := g.queue[0]
+			g.queue = g.queue[1:]
+			p.attempt++
+			p.member = m
+			p.deadline = now.Add(g.config.ackTimeout)
+			g.inFlight[p.id] = p
+			g.mu.Unlock()
+			return Delivery[T]{Result: p.result, Attempt: p.attempt, id: p.id, member: m}, nil
+		}
+		changed := g.changed
+		deadline, ok := g.nextDeadline()
+		g.mu.Unlock()
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if ok {
+			timer = time.NewTimer(deadline.Sub(now))
+			expired = timer.C
+		}
+		select {
+		case <-changed:
+		case <-expired:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return Delivery[T]{}, err
+		}
+	}
+}
+
+// Leave removes the member from its group and hands its unacknowledged deliveries to the other members, or to the
+// next member to join if it was the last one.
+func (m *Member[T]) Leave() {
+	g := m.group
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if m.left {
+		return
+	}
+	m.left = true
+	g.members--
+	g.requeue(m, time.Now())
+	g.signal() // wake up the Receive calls of the member, they return ErrLeftGroup
+}
+
+// Ack acknowledges the delivery. It returns ErrAckExpired if the delivery expired and was handed out again, the
+// message may then be processed twice.
+func (d Delivery[T]) Ack() error {
+	if d.member == nil {
+		return ErrInvalidDelivery
+	}
+	g := d.member.group
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.inFlight[d.id]
+	if !ok || p.member != d.member || p.attempt != d.Attempt {
+		return ErrAckExpired
+	}
+	delete(g.inFlight, d.id)
+	return nil
+}
@@ -0,0 +1,227 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// join joins the group, failing the test on error.
+func join[T any](t *testing.T, ps *PubSub[T], name, topic string, opts ...GroupOption) *Member[T] {
+	t.Helper()
+	member, err := ps.JoinGroup(name, topic, opts...)
+	assert.NoError(t, err)
+	return member
+}
+
+func TestPubSub_GroupDeliversEachMessageOnce(t *testing.T) {
+	ps := NewPubSub[int]()
+	members := []*Member[int]{join(t, ps, "pokedex", "pokemon"), join(t, ps, "pokedex", "pokemon")}
+	other := join(t, ps, "trainers", "*")
+	ch := make(chan Result[int], 100)
+	ps.Subscribe("pokemon", ch)
+
+	const messages = 100
+	for i := 0; i < messages; i++ {
+		assert.NoError(t, ps.Publish("pokemon", i))
+	}
+
+	var (
+		mu       sync.Mutex
+		received = map[int]int{}
+		wg       sync.WaitGroup
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, member := range members {
+		wg.Add(1)
+		go func(member *Member[int]) {
+			defer wg.Done()
+			for {
+				delivery, err := member.Receive(ctx)
+				if err != nil {
+					return
+				}
+				assert.NoError(t, delivery.Ack())
+				mu.Lock()
+				received[delivery.Value]++
+				done := len(received) == messages
+				mu.Unlock()
+				if done {
+					cancel()
+				}
+			}
+		}(member)
+	}
+	wg.Wait()
+
+	assert.Len(t, received, messages)
+	for value, count := range received {
+		assert.Equal(t, 1, count, "message %d should be delivered once", value)
+	}
+	assert.Len(t, ch, messages, "a plain subscriber should get every message")
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < messages; i++ {
+		delivery, err := other.Receive(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, i, delivery.Value, "another group should get its own copy of every message")
+		assert.NoError(t, delivery.Ack())
+	}
+}
+
+func TestPubSub_GroupRedeliversAfterAckTimeout(t *testing.T) {
+	ps := NewPubSub[string]()
+	slow := join(t, ps, "pokedex", "pokemon", WithAckTimeout(20*time.Millisecond))
+	fast := join(t, ps, "pokedex", "pokemon")
+	assert.NoError(t, ps.Publish("pokemon", "bulbasaur"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	first, err := slow.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first.Attempt)
+
+	// The slow member never acknowledges, the fast one gets the message once the ack timeout expires.
+	second, err := fast.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "bulbasaur", second.Value)
+	assert.Equal(t, 2, second.Attempt)
+	assert.NoError(t, second.Ack())
+	assert.ErrorIs(t, first.Ack(), ErrAckExpired)
+}
+
+func TestPubSub_GroupRebalancesWhenMemberLeaves(t *testing.T) {
+	ps := NewPubSub[string]()
+	leaving := join(t, ps, "pokedex", "pokemon")
+	staying := join(t, ps, "pokedex", "pokemon")
+	for _, value := range []string{"bulbasaur", "ivysaur", "venusaur"} {
+		assert.NoError(t, ps.Publish("pokemon", value))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		_, err := leaving.Receive(ctx)
+		assert.NoError(t, err)
+	}
+	leaving.Leave()
+	_, err := leaving.Receive(ctx)
+	assert.ErrorIs(t, err, ErrLeftGroup)
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		delivery, err := staying.Receive(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, delivery.Ack())
+		got = append(got, fmt.Sprintf("%s/%d", delivery.Value, delivery.Attempt))
+	}
+	assert.Equal(t, []string{"bulbasaur/2", "ivysaur/2", "venusaur/1"}, got, "unacknowledged messages go first")
+
+	staying.Leave()
+	assert.Equal(t, 1, ps.subscribers.Load().len(), "the group should outlive its last member")
+}
+
+func TestPubSub_GroupKeepsMessagesWithoutMembers(t *testing.T) {
+	ps := NewPubSub[string]()
+	member := join(t, ps, "pokedex", "pokemon")
+	assert.NoError(t, ps.Publish("pokemon", "bulbasaur"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := member.Receive(ctx)
+	assert.NoError(t, err)
+	member.Leave() // the last member leaves without acknowledging
+	assert.NoError(t, ps.Publish("pokemon", "ivysaur"))
+
+	member = join(t, ps, "pokedex", "pokemon")
+	var got []string
+	for i := 0; i < 2; i++ {
+		delivery, err := member.Receive(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, delivery.Ack())
+		got = append(got, fmt.Sprintf("%s/%d", delivery.Value, delivery.Attempt))
+	}
+	assert.Equal(t, []string{"bulbasaur/2", "ivysaur/1"}, got, "nothing should be lost while the group is empty")
+	assert.Zero(t, ps.Dropped())
+}
+
+func TestPubSub_DeleteGroup(t *testing.T) {
+	ps := NewPubSub[string]()
+	assert.ErrorIs(t, ps.DeleteGroup("pokedex", "pokemon"), ErrGroupNotFound)
+
+	member := join(t, ps, "pokedex", "pokemon")
+	assert.NoError(t, ps.Publish("pokemon", "bulbasaur"))
+	assert.ErrorIs(t, ps.DeleteGroup("pokedex", "pokemon"), ErrGroupHasMembers)
+
+	member.Leave()
+	assert.NoError(t, ps.DeleteGroup("pokedex", "pokemon"))
+	assert.Zero(t, ps.subscribers.Load().len())
+	assert.Equal(t, int64(1), ps.Dropped(), "the queued message should be dropped with the group")
+}
+
+func TestPubSub_JoinGroupConflictingOptions(t *testing.T) {
+	ps := NewPubSub[string]()
+	join(t, ps, "pokedex", "pokemon", WithAckTimeout(time.Second))
+
+	join(t, ps, "pokedex", "pokemon")
+	join(t, ps, "pokedex", "pokemon", WithAckTimeout(time.Second))
+	_, err := ps.JoinGroup("pokedex", "pokemon", WithAckTimeout(time.Minute))
+	assert.ErrorIs(t, err, ErrGroupConflict)
+	_, err = ps.JoinGroup("pokedex", "pokemon", WithMaxQueued(10))
+	assert.ErrorIs(t, err, ErrGroupConflict)
+
+	join(t, ps, "trainers", "pokemon", WithAckTimeout(time.Minute)) // another group has its own options
+}
+
+func TestDelivery_AckZeroValue(t *testing.T) {
+	assert.ErrorIs(t, Delivery[string]{}.Ack(), ErrInvalidDelivery)
+}
+
+func TestPubSub_GroupLeaveWakesUpReceive(t *testing.T) {
+	ps := NewPubSub[string]()
+	member := join(t, ps, "pokedex", "pokemon")
+
+	errs := make(chan error)
+	go func() {
+		_, err := member.Receive(context.Background())
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	member.Leave()
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrLeftGroup)
+	case <-time.After(time.Second):
+		t.Fatal("Receive should return when the member leaves")
+	}
+}
+
+func TestPubSub_GroupMaxQueued(t *testing.T) {
+	ps := NewPubSub[int]()
+	member := join(t, ps, "pokedex", "pokemon", WithMaxQueued(2))
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, ps.Publish("pokemon", i))
+	}
+	assert.Equal(t, int64(2), ps.Dropped())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var got []int
+	for {
+		delivery, err := member.Receive(ctx)
+		if err != nil {
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			break
+		}
+		assert.NoError(t, delivery.Ack())
+		got = append(got, delivery.Value)
+	}
+	assert.Equal(t, []int{0, 1}, got)
+}
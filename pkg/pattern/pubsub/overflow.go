@@ -0,0 +1,147 @@
+package pubsub
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSlowSubscriber is delivered to a subscriber disconnected by the Disconnect policy. A subscriber on an unbuffered
+// channel only gets it if it is receiving at that moment, Subscription.Disconnected reports the disconnection anyway.
+var ErrSlowSubscriber = errors.New("pubsub: subscriber disconnected for being too slow")
+
+// OverflowPolicy decides what Publish does when a subscriber channel is full.
+type OverflowPolicy int
+
+const (
+	// DropNewest drops the message being published, the default.
+	DropNewest OverflowPolicy = iota
+	// DropOldest drops the oldest buffered message to make room, the channel then acts as a ring buffer.
+	DropOldest
+	// Block waits for the subscriber up to the block timeout, then drops the message. It delays the delivery to the
+	// other subscribers of the topic.
+	Block
+	// Disconnect drops the message and unsubscribes the subscriber, delivering ErrSlowSubscriber on its channel.
+	// The error takes the place of the oldest buffered message, so use a buffered channel to be sure to receive it.
+	Disconnect
+)
+
+// defaultBlockTimeout is the block timeout of the Block policy when none is given.
+const defaultBlockTimeout = 100 * time.Millisecond
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscriptionConfig)
+
+type subscriptionConfig struct {
+	policy       OverflowPolicy
+	blockTimeout time.Duration
+}
+
+// WithOverflowPolicy sets what happens when the subscriber channel is full, DropNewest if not set.
+func WithOverflowPolicy(policy OverflowPolicy) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.policy = policy
+	}
+}
+
+// WithBlockTimeout sets how long the Block policy waits for the subscriber.
+func WithBlockTimeout(timeout time.Duration) SubscribeOption {
+	return func(c *subscriptionConfig) {
+		c.blockTimeout = timeout
+	}
+}
+
+// Subscription is a subscriber channel registered on a topic.
+type Subscription[T any] struct {
+	topic  string
+	ch     chan Result[T]
+	config subscriptionConfig
+
+	mu           sync.Mutex // mu serialises the deliveries, so that DropOldest only makes room for its own message.
+	dropped      atomic.Int64
+	disconnected atomic.Bool
+}
+
+func newSubscription[T any](topic string, ch chan Result[T], opts ...SubscribeOption) *Subscription[T] {
+	config := subscriptionConfig{blockTimeout: defaultBlockTimeout}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return &Subscription[T]{topic: topic, ch: ch, config: config}
+}
+
+// Dropped returns the number of messages dropped for this subscriber.
+func (s *Subscription[T]) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Disconnected reports whether the subscriber was disconnected by the Disconnect policy.
+func (s *Subscription[T]) Disconnected() bool {
+	return s.disconnected.Load()
+}
+
+// deliver sends a result according to the overflow policy. It returns the number of dropped messages and whether
+// the subscriber must be disconnected.
+func (s *Subscription[T]) deliver(result Result[T]) (dropped int64, disconnect bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disconnected.Load() {
+		return 0, false // disconnected while a concurrent Publish was delivering
+	}
+
+	select {
+	case s.ch <- result:
+		return 0, false
+	default:
+	}
+
+	switch s.config.policy {
+	case DropOldest:
+		if s.evictOldest() {
+			dropped++
+		}
+		select {
+		case s.ch <- result:
+		default:
+			dropped++ // unbuffered channel, there is no oldest message to drop
+		}
+	case Block:
+		timer := time.NewTimer(s.config.blockTimeout)
+		defer timer.Stop()
+		select {
+		case s.ch <- result:
+		case <-timer.C:
+			dropped++
+		}
+	case Disconnect:
+		dropped++
+		if s.evictOldest() {
+			dropped++ // make room for the error
+		}
+		s.disconnected.Store(true)
+		select {
+		case s.ch <- Result[T]{Err: ErrSlowSubscriber}:
+		default:
+			// Unbuffered channel and the subscriber is not receiving. Sending later would need a goroutine that
+			// could outlive the subscriber, or send on a channel it closed, so the error is not delivered.
+		}
+		disconnect = true
+	default:
+		dropped++
+	}
+
+	s.dropped.Add(dropped)
+	return dropped, disconnect
+}
+
+// evictOldest removes the oldest buffered message and reports whether there was one.
+func (s *Subscription[T]) evictOldest() bool {
+	select {
+	case <-s.ch:
+		return true
+	default:
+		return false
+	}
+}
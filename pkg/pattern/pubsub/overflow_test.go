@@ -0,0 +1,122 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSub_OverflowPolicy(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []SubscribeOption
+		want             []Result[int]
+		wantDropped      int64
+		wantDisconnected bool
+	}{
+		{
+			name:        "Drop newest by default",
+			want:        []Result[int]{{Value: 1}, {Value: 2}},
+			wantDropped: 2,
+		},
+		{
+			name:        "Drop oldest",
+			opts:        []SubscribeOption{WithOverflowPolicy(DropOldest)},
+			want:        []Result[int]{{Value: 3}, {Value: 4}},
+			wantDropped: 2,
+		},
+		{
+			name:        "Block with timeout",
+			opts:        []SubscribeOption{WithOverflowPolicy(Block), WithBlockTimeout(5 * time.Millisecond)},
+			want:        []Result[int]{{Value: 1}, {Value: 2}},
+			wantDropped: 2,
+		},
+		{
+			name:             "Disconnect",
+			opts:             []SubscribeOption{WithOverflowPolicy(Disconnect)},
+			want:             []Result[int]{{Value: 2}, {Err: ErrSlowSubscriber}},
+			wantDropped:      2, // the message that did not fit and the oldest one, to make room for the error
+			wantDisconnected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewPubSub[int]()
+			ch := make(chan Result[int], 2)
+			subscription := ps.Subscribe("topic", ch, tt.opts...)
+
+			for i := 1; i <= 4; i++ {
+				ps.Publish("topic", i)
+			}
+			close(ch)
+
+			var got []Result[int]
+			for result := range ch {
+				got = append(got, result)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantDropped, subscription.Dropped())
+			assert.Equal(t, tt.wantDropped, ps.Dropped())
+			assert.Equal(t, tt.wantDisconnected, subscription.Disconnected())
+			if tt.wantDisconnected {
+				assert.Zero(t, ps.subscribers.Load().len(), "a disconnected subscriber should be unsubscribed")
+			}
+		})
+	}
+}
+
+func TestPubSub_DisconnectUnbufferedSubscriber(t *testing.T) {
+	ps := NewPubSub[int]()
+	ch := make(chan Result[int]) // unbuffered and nobody receives
+	subscription := ps.Subscribe("topic", ch, WithOverflowPolicy(Disconnect))
+
+	ps.Publish("topic", 1)
+
+	// There is no room for the error, only the subscription reports the disconnection.
+	assert.True(t, subscription.Disconnected())
+	assert.Equal(t, int64(1), subscription.Dropped())
+	select {
+	case result := <-ch:
+		t.Fatalf("nothing should be delivered, got %v", result)
+	default:
+	}
+}
+
+func TestPubSub_BlockWaitsForSlowSubscriber(t *testing.T) {
+	ps := NewPubSub[int]()
+	ch := make(chan Result[int]) // unbuffered, every message waits for the subscriber
+	subscription := ps.Subscribe("topic", ch, WithOverflowPolicy(Block), WithBlockTimeout(time.Second))
+
+	received := make(chan []int)
+	go func() {
+		var values []int
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond) // slow, but within the block timeout
+			values = append(values, (<-ch).Value)
+		}
+		received <- values
+	}()
+
+	for i := 1; i <= 3; i++ {
+		ps.Publish("topic", i)
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, <-received)
+	assert.Zero(t, subscription.Dropped())
+}
+
+func TestPubSub_DropsAreCountedPerSubscription(t *testing.T) {
+	ps := NewPubSub[int]()
+	fast := ps.Subscribe("topic", make(chan Result[int], 10))
+	slow := ps.Subscribe("topic", make(chan Result[int], 1))
+
+	for i := 0; i < 5; i++ {
+		ps.Publish("topic", i)
+	}
+
+	assert.Zero(t, fast.Dropped())
+	assert.Equal(t, int64(4), slow.Dropped())
+	assert.Equal(t, int64(4), ps.Dropped())
+}
@@ -0,0 +1,194 @@
+// Package pubsub implements the publish/subscribe pattern.
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Result[T any] struct {
+	Value  T
+	Err    error
+	Offset int64 // Offset is the offset of the message in the log of its topic, when messages are retained.
+}
+
+// registry holds the subscriptions by topic. A registry is never modified once published, changes are made on a copy.
+type registry[T any] struct {
+	exact    map[string][]*Subscription[T] // exact holds the subscriptions to a single topic.
+	patterns map[string][]*Subscription[T] // patterns holds the subscriptions to a topic with wildcards.
+	groups   map[string][]*Group[T]        // groups holds the consumer groups by topic, with or without wildcards.
+}
+
+func newRegistry[T any]() registry[T] {
+	return registry[T]{
+		exact:    map[string][]*Subscription[T]{},
+		patterns: map[string][]*Subscription[T]{},
+		groups:   map[string][]*Group[T]{},
+	}
+}
+
+// index returns the map holding the subscriptions to topic.
+func (r registry[T]) index(topic string) map[string][]*Subscription[T] {
+	if isPattern(topic) {
+		return r.patterns
+	}
+	return r.exact
+}
+
+// group returns the named consumer group of topic, or nil.
+func (r registry[T]) group(name, topic string) *Group[T] {
+	for _, group := range r.groups[topic] {
+		if group.name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// len returns the number of subscribed topics and patterns.
+func (r registry[T]) len() int {
+	return len(r.exact) + len(r.patterns) + len(r.groups)
+}
+
+// PubSub delivers the messages published on a topic to the subscribers of that topic.
+//
+// The subscribers are kept in a copy-on-write registry: Subscribe and Unsubscribe are serialised and each replaces
+// the registry with an updated copy, while Publish reads the current registry without locking. Every operation
+// therefore takes effect at a single point in time: a Publish that starts after Subscribe returns reaches the new
+// subscriber, and one that starts after Unsubscribe returns does not.
+//
+// Topics are hierarchical, with tokens separated by dots such as "pokemon.fire.charizard", and a subscription can use
+// wildcards to receive from many topics at once: "pokemon.*.charizard" or "pokemon.>", see MatchTopic.
+//
+// What happens when a subscriber channel is full depends on the OverflowPolicy of the subscription, and every
+// dropped message is counted.
+//
+// With WithRetention, the messages are also retained in a log per topic, which durable subscriptions read from: see
+// SubscribeDurable.
+//
+// Subscribers can also share the messages of a topic as the members of a consumer group, see JoinGroup.
+type PubSub[T any] struct {
+	mu          sync.Mutex // mu serialises the changes to subscribers.
+	subscribers atomic.Pointer[registry[T]]
+	dropped     atomic.Int64
+
+	storage   Storage[T]
+	retention RetentionPolicy
+	now       func() time.Time
+	notifyMu  sync.Mutex
+	appended  chan struct{} // appended is closed and replaced whenever a message is retained.
+}
+
+func NewPubSub[T any](opts ...Option[T]) *PubSub[T] {
+	ps := &PubSub[T]{now: time.Now, appended: make(chan struct{})}
+	for _, opt := range opts {
+		opt(ps)
+	}
+	subscribers := newRegistry[T]()
+	ps.subscribers.Store(&subscribers)
+	return ps
+}
+
+// Subscribe registers ch on topic, which may contain wildcards, and returns the subscription.
+func (ps *PubSub[T]) Subscribe(topic string, ch chan Result[T], opts ...SubscribeOption) *Subscription[T] {
+	subscription := newSubscription(topic, ch, opts...)
+	ps.update(func(subscribers registry[T]) {
+		// Copy the slice as well, it may be read by a concurrent Publish.
+		index := subscribers.index(topic)
+		index[topic] = append(append([]*Subscription[T]{}, index[topic]...), subscription)
+	})
+	return subscription
+}
+
+// Unsubscribe removes the first subscription of ch on topic.
+func (ps *PubSub[T]) Unsubscribe(topic string, ch chan Result[T]) {
+	ps.remove(topic, func(subscription *Subscription[T]) bool { return subscription.ch == ch })
+}
+
+// Publish delivers message to the subscribers of topic, and of the patterns matching it. When messages are retained,
+// it returns the error of the storage, if any; the subscribers receive the message regardless.
+func (ps *PubSub[T]) Publish(topic string, message T) error {
+	result := Result[T]{Value: message}
+	var err error
+	if ps.storage != nil {
+		var retained Message[T]
+		retained, err = ps.retain(topic, message)
+		result.Offset = retained.Offset
+	}
+
+	subscribers := *ps.subscribers.Load()
+	for _, subscription := range subscribers.exact[topic] {
+		ps.deliver(subscription, result)
+	}
+	for pattern, subscriptions := range subscribers.patterns {
+		if MatchTopic(pattern, topic) {
+			for _, subscription := range subscriptions {
+				ps.deliver(subscription, result)
+			}
+		}
+	}
+	for topicOrPattern, groups := range subscribers.groups {
+		if MatchTopic(topicOrPattern, topic) {
+			for _, group := range groups {
+				if group.enqueue(result) {
+					ps.dropped.Add(1)
+				}
+			}
+		}
+	}
+	return err
+}
+
+// deliver delivers result to a subscription and disconnects it if its overflow policy says so.
+func (ps *PubSub[T]) deliver(subscription *Subscription[T], result Result[T]) {
+	dropped, disconnect := subscription.deliver(result)
+	ps.dropped.Add(dropped)
+	if disconnect {
+		ps.remove(subscription.topic, func(other *Subscription[T]) bool { return other == subscription })
+	}
+}
+
+// Dropped returns the number of messages dropped for all subscribers.
+func (ps *PubSub[T]) Dropped() int64 {
+	return ps.dropped.Load()
+}
+
+// remove removes the first subscription of topic that matches.
+func (ps *PubSub[T]) remove(topic string, match func(*Subscription[T]) bool) {
+	ps.update(func(subscribers registry[T]) {
+		index := subscribers.index(topic)
+		for i, subscription := range index[topic] {
+			if match(subscription) {
+				remaining := make([]*Subscription[T], 0, len(index[topic])-1)
+				remaining = append(append(remaining, index[topic][:i]...), index[topic][i+1:]...)
+				if len(remaining) == 0 {
+					delete(index, topic)
+				} else {
+					index[topic] = remaining
+				}
+				return
+			}
+		}
+	})
+}
+
+// update applies change to a copy of the registry and publishes the copy.
+func (ps *PubSub[T]) update(change func(subscribers registry[T])) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	current := *ps.subscribers.Load()
+	updated := newRegistry[T]()
+	for topic, subscriptions := range current.exact {
+		updated.exact[topic] = subscriptions
+	}
+	for pattern, subscriptions := range current.patterns {
+		updated.patterns[pattern] = subscriptions
+	}
+	for topic, groups := range current.groups {
+		updated.groups[topic] = groups
+	}
+	change(updated)
+	ps.subscribers.Store(&updated)
+}
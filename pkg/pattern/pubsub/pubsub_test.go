@@ -1,7 +1,10 @@
-package main
+package pubsub
 
 import (
 	"context"
+	"fmt"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -85,3 +88,54 @@ func TestPubSub(t *testing.T) {
 		})
 	}
 }
+
+func TestPubSub_ConcurrentStress(t *testing.T) {
+	const goroutines, rounds = 300, 20
+	// run goroutines in parallel even on a single CPU, so that the operations interleave
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(runtime.NumCPU(), 4)))
+	ps := NewPubSub[string]()
+	topics := []string{"topic1", "topic2", "topic3"}
+
+	start := make(chan struct{}) // start releases all goroutines at once to maximise contention
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			topic := topics[i%len(topics)]
+			// large enough to hold every message published while subscribed, so that none is dropped
+			ch := make(chan Result[string], goroutines*rounds*2)
+
+			for round := 0; round < rounds; round++ {
+				ps.Subscribe(topic, ch)
+				subscribed := fmt.Sprintf("subscribed-%d-%d", i, round)
+				ps.Publish(topic, subscribed)
+				assert.True(t, drainUntil(ch, subscribed), "a subscription must not be lost by a concurrent Subscribe or Unsubscribe")
+
+				ps.Unsubscribe(topic, ch)
+				unsubscribed := fmt.Sprintf("unsubscribed-%d-%d", i, round)
+				ps.Publish(topic, unsubscribed)
+				assert.False(t, drainUntil(ch, unsubscribed), "a Publish that starts after Unsubscribe must not deliver")
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Zero(t, ps.subscribers.Load().len(), "all subscriptions should be removed")
+}
+
+// drainUntil reads the buffered messages of ch and reports whether message was among them.
+func drainUntil(ch chan Result[string], message string) bool {
+	for {
+		select {
+		case result := <-ch:
+			if result.Value == message {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
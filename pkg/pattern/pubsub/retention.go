@@ -0,0 +1,241 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoRetention is returned by SubscribeDurable when the PubSub does not retain messages.
+	ErrNoRetention = errors.New("pubsub: messages are not retained")
+	// ErrPatternNotDurable is returned by SubscribeDurable for a topic with wildcards, only topics have a log.
+	ErrPatternNotDurable = errors.New("pubsub: a durable subscription needs a topic without wildcards")
+)
+
+// readBatch is the number of messages a durable subscription reads from the storage at once.
+const readBatch = 64
+
+// Option configures a PubSub.
+type Option[T any] func(*PubSub[T])
+
+// RetentionPolicy bounds the messages retained on each topic. A zero field means no bound.
+type RetentionPolicy struct {
+	MaxMessages int           // MaxMessages is the number of messages retained, 1 keeps the last value only.
+	MaxAge      time.Duration // MaxAge is how long a message is retained.
+}
+
+// WithRetention retains the published messages in storage, bounded by policy, so that durable subscribers can
+// replay them. The bounds are enforced on publish.
+func WithRetention[T any](storage Storage[T], policy RetentionPolicy) Option[T] {
+	return func(ps *PubSub[T]) {
+		ps.storage = storage
+		ps.retention = policy
+	}
+}
+
+type startMode int
+
+const (
+	startOffset startMode = iota
+	startLatest
+	startLastValue
+)
+
+// Start is where a durable subscription starts reading when its subscriber has not acknowledged any offset yet.
+type Start struct {
+	mode   startMode
+	offset int64
+}
+
+// FromOffset starts at offset, or at the oldest retained message if offset was trimmed. FromOffset(0) replays
+// everything retained.
+func FromOffset(offset int64) Start {
+	return Start{mode: startOffset, offset: offset}
+}
+
+// FromLatest starts with the next message published.
+func FromLatest() Start {
+	return Start{mode: startLatest}
+}
+
+// FromLastValue starts with the last retained message, if any, then the next ones.
+func FromLastValue() Start {
+	return Start{mode: startLastValue}
+}
+
+// retain appends message to the log of topic and trims the log according to the retention policy.
+func (ps *PubSub[T]) retain(topic string, message T) (Message[T], error) {
+	now := ps.now()
+	retained, err := ps.storage.Append(topic, message, now)
+	if err != nil {
+		return Message[T]{}, err
+	}
+	ps.notify()
+
+	trim := int64(0)
+	if ps.retention.MaxMessages > 0 {
+		trim = retained.Offset + 1 - int64(ps.retention.MaxMessages)
+	}
+	if ps.retention.MaxAge > 0 {
+		expired, err := ps.expired(topic, now.Add(-ps.retention.MaxAge))
+		if err != nil {
+			return retained, err
+		}
+		trim = max(trim, expired)
+	}
+	return retained, ps.storage.Trim(topic, trim)
+}
+
+// expired returns the offset of the first message of topic published after cutoff.
+func (ps *PubSub[T]) expired(topic string, cutoff time.Time) (int64, error) {
+	offset, _, err := ps.storage.Bounds(topic)
+	for err == nil {
+		var messages []Message[T]
+		messages, err = ps.storage.Read(topic, offset, readBatch)
+		if len(messages) == 0 {
+			break
+		}
+		for _, message := range messages {
+			if message.Time.After(cutoff) {
+				return message.Offset, nil
+			}
+			offset = message.Offset + 1
+		}
+	}
+	return offset, err
+}
+
+// notify wakes up the durable subscriptions waiting for a message.
+func (ps *PubSub[T]) notify() {
+	ps.notifyMu.Lock()
+	defer ps.notifyMu.Unlock()
+
+	close(ps.appended)
+	ps.appended = make(chan struct{})
+}
+
+// waitAppended returns a channel closed when the next message is retained.
+func (ps *PubSub[T]) waitAppended() <-chan struct{} {
+	ps.notifyMu.Lock()
+	defer ps.notifyMu.Unlock()
+
+	return ps.appended
+}
+
+// Durable is a subscription that reads the log of a topic, so that it receives the messages published before it
+// subscribed and resumes where its subscriber left off. It is identified by its name: a new durable subscription
+// with the same name and topic resumes after the last offset acknowledged, even after a restart if the storage
+// survives it.
+//
+// Unlike a Subscription, a Durable never drops a message: it waits for its subscriber, which only holds up this
+// subscription. Messages trimmed by the retention policy before they are read are skipped.
+type Durable[T any] struct {
+	name    string
+	topic   string
+	storage Storage[T]
+
+	mu     sync.Mutex // mu serialises the acknowledgements.
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// SubscribeDurable delivers the messages retained on topic to ch, starting after the last offset acknowledged by
+// name, or at start if there is none, until ctx is done or the subscription is closed. Each Result holds the offset
+// of its message, to acknowledge with Ack.
+func (ps *PubSub[T]) SubscribeDurable(
+	ctx context.Context, name, topic string, ch chan Result[T], start Start,
+) (*Durable[T], error) {
+	if ps.storage == nil {
+		return nil, ErrNoRetention
+	}
+	if isPattern(topic) {
+		return nil, fmt.Errorf("%w: %q", ErrPatternNotDurable, topic)
+	}
+
+	offset, err := ps.startOffset(name, topic, start)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	d := &Durable[T]{name: name, topic: topic, storage: ps.storage, cancel: cancel, done: make(chan struct{})}
+	go d.run(ctx, ps, offset, ch)
+	return d, nil
+}
+
+// startOffset returns the offset a durable subscription starts reading at.
+func (ps *PubSub[T]) startOffset(name, topic string, start Start) (int64, error) {
+	acked, ok, err := ps.storage.Committed(name, topic)
+	if err != nil || ok {
+		return acked + 1, err
+	}
+	first, next, err := ps.storage.Bounds(topic)
+	switch start.mode {
+	case startLatest:
+		return next, err
+	case startLastValue:
+		return max(first, next-1), err
+	default:
+		return start.offset, err
+	}
+}
+
+// run delivers the messages from offset until ctx is done or the storage fails.
+func (d *Durable[T]) run(ctx context.Context, ps *PubSub[T], offset int64, ch chan Result[T]) {
+	defer close(d.done)
+
+	for {
+		// Take the notification channel before reading, so that a message appended after the read wakes us up.
+		appended := ps.waitAppended()
+		messages, err := d.storage.Read(d.topic, offset, readBatch)
+		if err != nil {
+			d.err = err
+			return
+		}
+		for _, message := range messages {
+			select {
+			case ch <- Result[T]{Value: message.Value, Offset: message.Offset}:
+				offset = message.Offset + 1
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(messages) > 0 {
+			continue
+		}
+		select {
+		case <-appended:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Name returns the name identifying the subscriber.
+func (d *Durable[T]) Name() string {
+	return d.name
+}
+
+// Ack acknowledges the messages up to offset, the subscription resumes after it if it is subscribed again.
+// Acknowledging an offset lower than the last one acknowledged does nothing.
+func (d *Durable[T]) Ack(offset int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	acked, ok, err := d.storage.Committed(d.name, d.topic)
+	if err != nil || (ok && offset <= acked) {
+		return err
+	}
+	return d.storage.Commit(d.name, d.topic, offset)
+}
+
+// Close stops the subscription and waits for it, the channel is left open. It returns the storage error that
+// stopped the subscription, if any.
+func (d *Durable[T]) Close() error {
+	d.cancel()
+	<-d.done
+	return d.err
+}
@@ -0,0 +1,163 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSub_SubscribeDurable(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      Start
+		wantReplay []Result[string]
+	}{
+		{
+			name:       "From offset",
+			start:      FromOffset(2),
+			wantReplay: []Result[string]{{Value: "venusaur", Offset: 2}, {Value: "charmander", Offset: 3}},
+		},
+		{
+			name:  "From trimmed offset",
+			start: FromOffset(0),
+			wantReplay: []Result[string]{
+				{Value: "ivysaur", Offset: 1}, {Value: "venusaur", Offset: 2}, {Value: "charmander", Offset: 3},
+			},
+		},
+		{
+			name:  "From latest",
+			start: FromLatest(),
+		},
+		{
+			name:       "From last value",
+			start:      FromLastValue(),
+			wantReplay: []Result[string]{{Value: "charmander", Offset: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewPubSub(WithRetention[string](NewMemoryStorage[string](), RetentionPolicy{MaxMessages: 3}))
+			for _, value := range []string{"bulbasaur", "ivysaur", "venusaur", "charmander"} {
+				assert.NoError(t, ps.Publish("pokemon", value))
+			}
+
+			ch := make(chan Result[string], 10)
+			durable, err := ps.SubscribeDurable(context.Background(), "pokedex", "pokemon", ch, tt.start)
+			assert.NoError(t, err)
+			defer durable.Close()
+
+			var replay []Result[string]
+			for range tt.wantReplay {
+				replay = append(replay, receive(t, ch))
+			}
+			assert.Equal(t, tt.wantReplay, replay)
+
+			assert.NoError(t, ps.Publish("pokemon", "charmeleon"))
+			assert.Equal(t, Result[string]{Value: "charmeleon", Offset: 4}, receive(t, ch))
+		})
+	}
+}
+
+func TestPubSub_DurableResumesAfterAck(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := OpenFileStorage[string](dir)
+	assert.NoError(t, err)
+	ps := NewPubSub(WithRetention[string](storage, RetentionPolicy{}))
+	for _, value := range []string{"bulbasaur", "ivysaur", "venusaur"} {
+		assert.NoError(t, ps.Publish("pokemon", value))
+	}
+
+	ch := make(chan Result[string], 10)
+	durable, err := ps.SubscribeDurable(context.Background(), "pokedex", "pokemon", ch, FromOffset(0))
+	assert.NoError(t, err)
+	result := receive(t, ch)
+	assert.Equal(t, "bulbasaur", result.Value)
+	assert.NoError(t, durable.Ack(result.Offset))
+	assert.NoError(t, durable.Ack(result.Offset-1), "an older offset should be ignored")
+	assert.NoError(t, durable.Close())
+	assert.NoError(t, storage.Close())
+
+	// Restart from the same directory.
+	storage, err = OpenFileStorage[string](dir)
+	assert.NoError(t, err)
+	defer storage.Close()
+	ps = NewPubSub(WithRetention[string](storage, RetentionPolicy{}))
+
+	ch = make(chan Result[string], 10)
+	durable, err = ps.SubscribeDurable(context.Background(), "pokedex", "pokemon", ch, FromLatest())
+	assert.NoError(t, err)
+	defer durable.Close()
+	assert.Equal(t, Result[string]{Value: "ivysaur", Offset: 1}, receive(t, ch), "it should resume after the ack")
+	assert.Equal(t, Result[string]{Value: "venusaur", Offset: 2}, receive(t, ch))
+}
+
+func TestPubSub_RetentionMaxAge(t *testing.T) {
+	storage := NewMemoryStorage[string]()
+	ps := NewPubSub(WithRetention[string](storage, RetentionPolicy{MaxAge: time.Minute}))
+	now := time.Date(2023, 9, 26, 0, 0, 0, 0, time.UTC)
+	ps.now = func() time.Time { return now }
+
+	assert.NoError(t, ps.Publish("pokemon", "bulbasaur"))
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, ps.Publish("pokemon", "ivysaur"))
+	now = now.Add(45 * time.Second)
+	assert.NoError(t, ps.Publish("pokemon", "venusaur"))
+
+	messages, err := storage.Read("pokemon", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ivysaur", "venusaur"}, values(messages))
+}
+
+func TestPubSub_LiveSubscribersGetOffsets(t *testing.T) {
+	ps := NewPubSub(WithRetention[string](NewMemoryStorage[string](), RetentionPolicy{MaxMessages: 1}))
+	ch := make(chan Result[string], 2)
+	ps.Subscribe("pokemon", ch)
+
+	assert.NoError(t, ps.Publish("pokemon", "bulbasaur"))
+	assert.NoError(t, ps.Publish("pokemon", "ivysaur"))
+
+	assert.Equal(t, Result[string]{Value: "bulbasaur", Offset: 0}, <-ch)
+	assert.Equal(t, Result[string]{Value: "ivysaur", Offset: 1}, <-ch)
+}
+
+func TestPubSub_SubscribeDurableErrors(t *testing.T) {
+	ch := make(chan Result[string])
+
+	_, err := NewPubSub[string]().SubscribeDurable(context.Background(), "pokedex", "pokemon", ch, FromLatest())
+	assert.ErrorIs(t, err, ErrNoRetention)
+
+	ps := NewPubSub(WithRetention[string](NewMemoryStorage[string](), RetentionPolicy{}))
+	_, err = ps.SubscribeDurable(context.Background(), "pokedex", "pokemon.>", ch, FromLatest())
+	assert.ErrorIs(t, err, ErrPatternNotDurable)
+}
+
+func TestPubSub_DurableStopsWithContext(t *testing.T) {
+	ps := NewPubSub(WithRetention[string](NewMemoryStorage[string](), RetentionPolicy{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Result[string]) // nobody receives
+	durable, err := ps.SubscribeDurable(ctx, "pokedex", "pokemon", ch, FromLatest())
+	assert.NoError(t, err)
+	assert.NoError(t, ps.Publish("pokemon", "bulbasaur"))
+
+	cancel()
+	select {
+	case <-durable.done:
+	case <-time.After(time.Second):
+		t.Fatal("the durable subscription should stop when its context is done")
+	}
+	assert.NoError(t, durable.Close())
+}
+
+func receive[T any](t *testing.T, ch chan Result[T]) Result[T] {
+	t.Helper()
+	select {
+	case result := <-ch:
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a message")
+		return Result[T]{}
+	}
+}
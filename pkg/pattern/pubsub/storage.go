@@ -0,0 +1,144 @@
+package pubsub
+
+import (
+	"sync"
+	"time"
+)
+
+// Message is a message retained in the log of a topic.
+type Message[T any] struct {
+	Topic  string    `json:"topic"`
+	Offset int64     `json:"offset"`
+	Time   time.Time `json:"time"`
+	Value  T         `json:"value"`
+}
+
+// Storage retains the messages published on each topic in an append-only log, and the offsets acknowledged by the
+// durable subscribers. Offsets start at zero and grow by one per message; trimming a log never reuses them.
+//
+// Implementations must be safe for concurrent use.
+type Storage[T any] interface {
+	// Append adds value to the log of topic and returns it as a message with its offset.
+	Append(topic string, value T, at time.Time) (Message[T], error)
+	// Read returns up to limit messages of topic starting at offset, or at the oldest retained message if offset was
+	// trimmed.
+	Read(topic string, offset int64, limit int) ([]Message[T], error)
+	// Bounds returns the offset of the oldest retained message of topic and the offset of the next message.
+	Bounds(topic string) (first, next int64, err error)
+	// Trim removes the messages of topic before offset.
+	Trim(topic string, offset int64) error
+	// Commit records offset as acknowledged by the named subscriber of topic.
+	Commit(name, topic string, offset int64) error
+	// Committed returns the offset acknowledged by the named subscriber of topic, if any.
+	Committed(name, topic string) (offset int64, ok bool, err error)
+}
+
+// MemoryStorage is a Storage kept in memory, it does not survive a restart.
+type MemoryStorage[T any] struct {
+	mu      sync.RWMutex
+	logs    map[string]*memoryLog[T]
+	offsets map[consumer]int64
+}
+
+// memoryLog is the log of a topic, messages[i] has the offset first+i.
+type memoryLog[T any] struct {
+	first    int64
+	messages []Message[T]
+}
+
+// consumer identifies the offsets acknowledged by a named subscriber of a topic.
+type consumer struct {
+	Name  string `json:"name"`
+	Topic string `json:"topic"`
+}
+
+func NewMemoryStorage[T any]() *MemoryStorage[T] {
+	return &MemoryStorage[T]{logs: map[string]*memoryLog[T]{}, offsets: map[consumer]int64{}}
+}
+
+func (s *MemoryStorage[T]) Append(topic string, value T, at time.Time) (Message[T], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log := s.log(topic)
+	message := Message[T]{Topic: topic, Offset: log.first + int64(len(log.messages)), Time: at, Value: value}
+	log.messages = append(log.messages, message)
+	return message, nil
+}
+
+func (s *MemoryStorage[T]) Read(topic string, offset int64, limit int) ([]Message[T], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	log, ok := s.logs[topic]
+	if !ok {
+		return nil, nil
+	}
+	start := max(offset-log.first, 0)
+	if start >= int64(len(log.messages)) {
+		return nil, nil
+	}
+	end := min(start+int64(limit), int64(len(log.messages)))
+	return append([]Message[T]{}, log.messages[start:end]...), nil
+}
+
+func (s *MemoryStorage[T]) Bounds(topic string) (first, next int64, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	log, ok := s.logs[topic]
+	if !ok {
+		return 0, 0, nil
+	}
+	return log.first, log.first + int64(len(log.messages)), nil
+}
+
+func (s *MemoryStorage[T]) Trim(topic string, offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trim(topic, offset)
+	return nil
+}
+
+func (s *MemoryStorage[T]) Commit(name, topic string, offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offsets[consumer{Name: name, Topic: topic}] = offset
+	return nil
+}
+
+func (s *MemoryStorage[T]) Committed(name, topic string) (offset int64, ok bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	offset, ok = s.offsets[consumer{Name: name, Topic: topic}]
+	return offset, ok, nil
+}
+
+// log returns the log of topic, creating it if needed. It must be called with mu held.
+func (s *MemoryStorage[T]) log(topic string) *memoryLog[T] {
+	log, ok := s.logs[topic]
+	if !ok {
+		log = &memoryLog[T]{}
+		s.logs[topic] = log
+	}
+	return log
+}
+
+// trim removes the messages of topic before offset and reports whether any was removed. It must be called with mu
+// held.
+func (s *MemoryStorage[T]) trim(topic string, offset int64) bool {
+	log, ok := s.logs[topic]
+	if !ok || offset <= log.first {
+		return false
+	}
+	n := min(offset-log.first, int64(len(log.messages)))
+	// Clear the trimmed messages so that their values can be garbage collected, the backing array itself is released
+	// the next time append grows the slice, which only copies the remaining messages.
+	clear(log.messages[:n])
+	log.messages = log.messages[n:]
+	log.first += n
+	return n > 0
+}
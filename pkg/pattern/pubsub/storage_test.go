@@ -0,0 +1,192 @@
+package pubsub
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T) {
+	storages := []struct {
+		name string
+		open func(t *testing.T) Storage[string]
+	}{
+		{
+			name: "Memory",
+			open: func(t *testing.T) Storage[string] { return NewMemoryStorage[string]() },
+		},
+		{
+			name: "File",
+			open: func(t *testing.T) Storage[string] {
+				storage, err := OpenFileStorage[string](t.TempDir())
+				assert.NoError(t, err)
+				t.Cleanup(func() { assert.NoError(t, storage.Close()) })
+				return storage
+			},
+		},
+	}
+
+	for _, tt := range storages {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := tt.open(t)
+			at := time.Date(2023, 9, 26, 0, 0, 0, 0, time.UTC)
+
+			for i, value := range []string{"bulbasaur", "ivysaur", "venusaur"} {
+				message, err := storage.Append("pokemon", value, at)
+				assert.NoError(t, err)
+				assert.Equal(t, Message[string]{Topic: "pokemon", Offset: int64(i), Time: at, Value: value}, message)
+			}
+
+			messages, err := storage.Read("pokemon", 1, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"ivysaur", "venusaur"}, values(messages))
+
+			messages, err = storage.Read("pokemon", 0, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"bulbasaur"}, values(messages))
+
+			assert.NoError(t, storage.Trim("pokemon", 2))
+			first, next, err := storage.Bounds("pokemon")
+			assert.NoError(t, err)
+			assert.Equal(t, []int64{2, 3}, []int64{first, next})
+
+			messages, err = storage.Read("pokemon", 0, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"venusaur"}, values(messages), "a trimmed offset should read from the oldest message")
+
+			messages, err = storage.Read("trainer", 0, 10)
+			assert.NoError(t, err)
+			assert.Empty(t, messages)
+
+			_, ok, err := storage.Committed("pokedex", "pokemon")
+			assert.NoError(t, err)
+			assert.False(t, ok)
+			assert.NoError(t, storage.Commit("pokedex", "pokemon", 2))
+			offset, ok, err := storage.Committed("pokedex", "pokemon")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, int64(2), offset)
+		})
+	}
+}
+
+func TestFileStorage_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := OpenFileStorage[string](dir)
+	assert.NoError(t, err)
+	for _, value := range []string{"bulbasaur", "ivysaur", "venusaur", "charmander"} {
+		_, err := storage.Append("pokemon.grass/poison", value, time.Now())
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, storage.Trim("pokemon.grass/poison", 1))
+	assert.NoError(t, storage.Commit("pokedex", "pokemon.grass/poison", 2))
+	assert.NoError(t, storage.Close())
+
+	storage, err = OpenFileStorage[string](dir)
+	assert.NoError(t, err)
+	defer storage.Close()
+
+	first, next, err := storage.Bounds("pokemon.grass/poison")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 4}, []int64{first, next})
+	messages, err := storage.Read("pokemon.grass/poison", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ivysaur", "venusaur", "charmander"}, values(messages))
+	offset, ok, err := storage.Committed("pokedex", "pokemon.grass/poison")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), offset)
+
+	message, err := storage.Append("pokemon.grass/poison", "charmeleon", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), message.Offset, "offsets should carry on after a restart")
+}
+
+func TestFileStorage_TrimsAndCommitsInBatches(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := OpenFileStorage[int](dir)
+	assert.NoError(t, err)
+	for i := 0; i < 10*compactMin; i++ {
+		_, err := storage.Append("pokemon", i, time.Now())
+		assert.NoError(t, err)
+		assert.NoError(t, storage.Trim("pokemon", int64(i-2))) // keep the last three messages
+		assert.NoError(t, storage.Commit("pokedex", "pokemon", int64(i)))
+	}
+	assert.NoError(t, storage.Close())
+
+	// the files hold at most a few batches of obsolete lines
+	assert.Less(t, countLines(t, filepath.Join(dir, "pokemon"+logExt)), 3*compactMin)
+	assert.Less(t, countLines(t, filepath.Join(dir, offsetsFile)), 2*compactMin)
+
+	storage, err = OpenFileStorage[int](dir)
+	assert.NoError(t, err)
+	defer storage.Close()
+	messages, err := storage.Read("pokemon", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10*compactMin - 3, 10*compactMin - 2, 10*compactMin - 1}, values(messages))
+	offset, ok, err := storage.Committed("pokedex", "pokemon")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(10*compactMin-1), offset)
+}
+
+func TestFileStorage_TruncatesTornLine(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := OpenFileStorage[string](dir)
+	assert.NoError(t, err)
+	for _, value := range []string{"bulbasaur", "ivysaur"} {
+		_, err := storage.Append("pokemon", value, time.Now())
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, storage.Commit("pokedex", "pokemon", 0))
+	assert.NoError(t, storage.Close())
+
+	// simulate a crash in the middle of writing a line
+	for _, name := range []string{"pokemon" + logExt, offsetsFile} {
+		file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_APPEND, 0o644)
+		assert.NoError(t, err)
+		_, err = file.WriteString(`{"topic":"pokemon","off`)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+
+	storage, err = OpenFileStorage[string](dir)
+	assert.NoError(t, err)
+	messages, err := storage.Read("pokemon", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bulbasaur", "ivysaur"}, values(messages))
+	message, err := storage.Append("pokemon", "venusaur", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), message.Offset)
+	assert.NoError(t, storage.Close())
+
+	storage, err = OpenFileStorage[string](dir)
+	assert.NoError(t, err)
+	defer storage.Close()
+	messages, err = storage.Read("pokemon", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bulbasaur", "ivysaur", "venusaur"}, values(messages))
+	offset, ok, err := storage.Committed("pokedex", "pokemon")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), offset)
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return bytes.Count(data, []byte("\n"))
+}
+
+func values[T any](messages []Message[T]) []T {
+	var values []T
+	for _, message := range messages {
+		values = append(values, message.Value)
+	}
+	return values
+}
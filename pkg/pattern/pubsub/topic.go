@@ -0,0 +1,42 @@
+package pubsub
+
+import "strings"
+
+const (
+	// TopicSeparator separates the tokens of a hierarchical topic, such as "pokemon.fire.charizard".
+	TopicSeparator = "."
+	// AnyToken matches exactly one token of a topic.
+	AnyToken = "*"
+	// RestTokens, or its alias RestTokensAlias, matches one or more trailing tokens of a topic.
+	RestTokens      = ">"
+	RestTokensAlias = "#"
+)
+
+// isPattern reports whether a subscription topic contains wildcards.
+func isPattern(topic string) bool {
+	for _, token := range strings.Split(topic, TopicSeparator) {
+		if token == AnyToken || token == RestTokens || token == RestTokensAlias {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTopic reports whether topic matches pattern, where "*" matches one token and a trailing ">" or "#" matches
+// the rest of the topic, one token at least. Wildcards only match whole tokens, and ">" or "#" anywhere but at the
+// end is a plain token.
+func MatchTopic(pattern, topic string) bool {
+	patternTokens := strings.Split(pattern, TopicSeparator)
+	topicTokens := strings.Split(topic, TopicSeparator)
+
+	for i, token := range patternTokens {
+		last := i == len(patternTokens)-1
+		if last && (token == RestTokens || token == RestTokensAlias) {
+			return len(topicTokens) > i
+		}
+		if i >= len(topicTokens) || (token != AnyToken && token != topicTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(topicTokens)
+}
@@ -0,0 +1,81 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{name: "exact", pattern: "pokemon.fire", topic: "pokemon.fire", want: true},
+		{name: "exact mismatch", pattern: "pokemon.fire", topic: "pokemon.water", want: false},
+		{name: "any token", pattern: "pokemon.*.charizard", topic: "pokemon.fire.charizard", want: true},
+		{name: "any token is not empty", pattern: "pokemon.*", topic: "pokemon", want: false},
+		{name: "any token is a single token", pattern: "pokemon.*", topic: "pokemon.fire.charizard", want: false},
+		{name: "rest tokens", pattern: "pokemon.>", topic: "pokemon.fire.charizard", want: true},
+		{name: "rest tokens alias", pattern: "pokemon.#", topic: "pokemon.fire", want: true},
+		{name: "rest tokens is not empty", pattern: "pokemon.>", topic: "pokemon", want: false},
+		{name: "rest tokens only", pattern: ">", topic: "pokemon.fire", want: true},
+		{name: "rest tokens not last is literal", pattern: "pokemon.>.charizard", topic: "pokemon.fire.charizard", want: false},
+		{name: "literal rest tokens", pattern: "pokemon.>.charizard", topic: "pokemon.>.charizard", want: true},
+		{name: "partial token", pattern: "poke*", topic: "pokemon", want: false},
+		{name: "longer topic", pattern: "pokemon.fire", topic: "pokemon.fire.charizard", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchTopic(tt.pattern, tt.topic))
+		})
+	}
+}
+
+func TestPubSub_Wildcards(t *testing.T) {
+	ps := NewPubSub[string]()
+	all := make(chan Result[string], 10)
+	fire := make(chan Result[string], 10)
+	charizard := make(chan Result[string], 10)
+	ps.Subscribe("pokemon.>", all)
+	ps.Subscribe("pokemon.fire.*", fire)
+	ps.Subscribe("pokemon.fire.charizard", charizard)
+
+	ps.Publish("pokemon.fire.charizard", "charizard")
+	ps.Publish("pokemon.fire.vulpix", "vulpix")
+	ps.Publish("pokemon.water.squirtle", "squirtle")
+	ps.Publish("trainer.ash", "ash")
+
+	assert.Equal(t, []string{"charizard", "vulpix", "squirtle"}, drain(all))
+	assert.Equal(t, []string{"charizard", "vulpix"}, drain(fire))
+	assert.Equal(t, []string{"charizard"}, drain(charizard))
+
+	ps.Unsubscribe("pokemon.>", all)
+	ps.Unsubscribe("pokemon.fire.*", fire)
+	ps.Unsubscribe("pokemon.fire.charizard", charizard)
+	assert.Zero(t, ps.subscribers.Load().len(), "no topic should be left behind")
+}
+
+func TestPubSub_WildcardDisconnect(t *testing.T) {
+	ps := NewPubSub[string]()
+	ch := make(chan Result[string], 1)
+	subscription := ps.Subscribe("pokemon.*", ch, WithOverflowPolicy(Disconnect))
+
+	ps.Publish("pokemon.fire", "charmander")
+	ps.Publish("pokemon.fire", "charizard")
+
+	assert.True(t, subscription.Disconnected())
+	assert.Equal(t, Result[string]{Err: ErrSlowSubscriber}, <-ch)
+	assert.Zero(t, ps.subscribers.Load().len(), "the pattern should be removed, not the published topic")
+}
+
+// drain returns the values buffered in ch.
+func drain(ch chan Result[string]) []string {
+	var values []string
+	for len(ch) > 0 {
+		values = append(values, (<-ch).Value)
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+package pattern
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes how many times and how often a failed job is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values below 1 mean a single attempt.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, zero means no cap.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each attempt, values below 1 default to 2.
+	Multiplier float64
+	// Jitter is the fraction of each delay, between 0 and 1, that is randomised to spread retries out.
+	Jitter float64
+	// RetryIf reports whether an error is worth retrying, nil retries every error except context errors.
+	RetryIf func(error) bool
+}
+
+// Backoff returns the delay to wait after the given attempt (starting at 1) failed.
+// Without MaxBackoff, the delay is capped at the longest time.Duration rather than overflowing.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	limit := float64(math.MaxInt64)
+	if p.MaxBackoff > 0 {
+		limit = float64(p.MaxBackoff)
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt && backoff > 0 && backoff < limit; i++ {
+		backoff *= multiplier
+	}
+	backoff = min(backoff, limit)
+
+	jitter := min(max(p.Jitter, 0), 1)
+	backoff -= backoff * jitter * rand.Float64()
+	if backoff >= float64(math.MaxInt64) {
+		return math.MaxInt64 // float64(math.MaxInt64) rounds up, it does not convert back.
+	}
+	return time.Duration(backoff)
+}
+
+// shouldRetry reports whether err is retryable according to the policy.
+func (p RetryPolicy) shouldRetry(err error) bool {
+	if p.RetryIf != nil {
+		return p.RetryIf(err)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// wait sleeps for the backoff of the given attempt, it returns false without waiting
+// if the context would expire before the next attempt can start.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	backoff := p.Backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+		return false
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
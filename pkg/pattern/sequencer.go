@@ -0,0 +1,64 @@
+package pattern
+
+import (
+	"context"
+	"sync"
+)
+
+// Sequencer emits results in submission order. At most window jobs may be submitted and not yet emitted,
+// so a slow job at the head of the line blocks new submissions instead of growing the reorder buffer.
+type Sequencer[T any, U any] struct {
+	slots   chan struct{} // slots is a semaphore holding one token per job submitted and not yet emitted.
+	results chan<- Result[T, U]
+
+	mu      sync.Mutex
+	next    int // next is the sequence number of the next result to emit.
+	pending map[int]sequenced[T, U]
+}
+
+// sequenced holds a finished result waiting for its turn.
+type sequenced[T any, U any] struct {
+	result Result[T, U]
+	emit   bool
+}
+
+// NewSequencer creates a Sequencer writing to results with a reorder window of window jobs, minimum one.
+func NewSequencer[T any, U any](window int, results chan<- Result[T, U]) *Sequencer[T, U] {
+	return &Sequencer[T, U]{
+		slots:   make(chan struct{}, max(window, 1)),
+		results: results,
+		pending: make(map[int]sequenced[T, U]),
+	}
+}
+
+// Acquire reserves room for the next job, blocking while the window is full.
+// It returns false if the context is done first. Jobs must be numbered in the order their slots were acquired.
+func (s *Sequencer[T, U]) Acquire(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case s.slots <- struct{}{}:
+		return true
+	}
+}
+
+// Done hands over the result of job number seq (starting at 0) and emits every result now in order.
+// Results with emit set to false, such as dead-lettered jobs, only advance the sequence.
+func (s *Sequencer[T, U]) Done(seq int, result Result[T, U], emit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[seq] = sequenced[T, U]{result: result, emit: emit}
+	for {
+		next, ok := s.pending[s.next]
+		if !ok {
+			return
+		}
+		delete(s.pending, s.next)
+		s.next++
+		if next.emit {
+			s.results <- next.result
+		}
+		<-s.slots
+	}
+}
@@ -0,0 +1,80 @@
+package pattern
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequencer_Done(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []int // order in which jobs finish
+		skip  map[int]bool
+		want  []int // job IDs emitted
+	}{
+		{name: "In order", order: []int{0, 1, 2, 3}, want: []int{0, 1, 2, 3}},
+		{name: "Reversed", order: []int{3, 2, 1, 0}, want: []int{0, 1, 2, 3}},
+		{name: "Shuffled", order: []int{2, 0, 3, 1}, want: []int{0, 1, 2, 3}},
+		{name: "Skipped results", order: []int{1, 3, 0, 2}, skip: map[int]bool{1: true}, want: []int{0, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make(chan Result[int, int], len(tt.order))
+			sequencer := NewSequencer(len(tt.order), results)
+			for range tt.order {
+				assert.True(t, sequencer.Acquire(context.Background()))
+			}
+
+			for _, seq := range tt.order {
+				sequencer.Done(seq, Result[int, int]{Job: Job[int]{ID: seq}}, !tt.skip[seq])
+			}
+			close(results)
+
+			var got []int
+			for result := range results {
+				got = append(got, result.Job.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSequencer_Backpressure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	results := make(chan Result[int, int], 10)
+	sequencer := NewSequencer(2, results)
+	assert.True(t, sequencer.Acquire(ctx))
+	assert.True(t, sequencer.Acquire(ctx))
+
+	// The head of the line is slow, finishing later jobs does not free the window.
+	sequencer.Done(1, Result[int, int]{Job: Job[int]{ID: 1}}, true)
+	acquired := make(chan bool)
+	go func() { acquired <- sequencer.Acquire(ctx) }()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected Acquire to block while the head of the line is pending")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Empty(t, results)
+
+	// Once the head finishes both results are emitted and the window opens up.
+	sequencer.Done(0, Result[int, int]{Job: Job[int]{ID: 0}}, true)
+	assert.True(t, <-acquired)
+	assert.Len(t, results, 2)
+}
+
+func TestSequencer_AcquireCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sequencer := NewSequencer(1, make(chan Result[int, int]))
+	assert.True(t, sequencer.Acquire(ctx))
+
+	cancel()
+	assert.False(t, sequencer.Acquire(ctx))
+}
@@ -0,0 +1,303 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+var (
+	ErrQueueClosed     = errors.New("priority queue closed")
+	ErrInvalidPriority = errors.New("invalid priority")
+)
+
+// DequeuePolicy decides which priority level the next job is taken from.
+type DequeuePolicy int
+
+const (
+	// StrictPriority always takes the job from the highest non-empty priority level.
+	StrictPriority DequeuePolicy = iota
+	// WeightedFair shares the workers between non-empty priority levels according to their weights.
+	WeightedFair
+)
+
+// PriorityConfig configures a PriorityQueue.
+type PriorityConfig struct {
+	// Levels is the number of priority levels, 0 being the highest priority. Values below 1 mean a single level.
+	Levels int
+	// Policy decides which level the next job is taken from.
+	Policy DequeuePolicy
+	// Weights holds the WeightedFair weight of each level, by default level i weighs Levels-i.
+	Weights []int
+	// MaxWait protects low priorities from starvation: a job waiting longer than MaxWait is taken
+	// before any other job, regardless of its priority. Zero disables starvation protection.
+	MaxWait time.Duration
+	// Capacity is the number of jobs each level can hold before Submit blocks, 1024 by default.
+	Capacity int
+}
+
+// PriorityQueue feeds jobs to a pool by priority. Jobs stay in the queue until a worker is ready to take them,
+// so a job submitted with a higher priority overtakes every queued job of lower priority.
+type PriorityQueue[T any] struct {
+	config PriorityConfig
+	out    chan pattern.Job[T]
+	notify chan struct{} // notify wakes up the dispatcher when the queue changes.
+	done   chan struct{} // done is closed once the dispatcher exits.
+	space  []chan struct{}
+
+	mu      sync.Mutex
+	levels  [][]queued[T]
+	current []int // current holds the smooth weighted round-robin credit of each level.
+	closed  bool
+}
+
+// queued holds a job waiting in the queue.
+type queued[T any] struct {
+	job        pattern.Job[T]
+	enqueuedAt time.Time
+}
+
+// NewPriorityQueue creates a PriorityQueue, its Jobs channel is closed once the queue is closed and drained,
+// or the context is cancelled. Once the context is cancelled, the queued jobs are dropped and Submit returns
+// ErrQueueClosed.
+func NewPriorityQueue[T any](ctx context.Context, config PriorityConfig) *PriorityQueue[T] {
+	config.Levels = max(config.Levels, 1)
+	if config.Capacity < 1 {
+		config.Capacity = 1024
+	}
+	weights := make([]int, config.Levels)
+	for i := range weights {
+		weights[i] = config.Levels - i
+		if i < len(config.Weights) && config.Weights[i] > 0 {
+			weights[i] = config.Weights[i]
+		}
+	}
+	config.Weights = weights
+
+	q := &PriorityQueue[T]{
+		config:  config,
+		out:     make(chan pattern.Job[T]),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		space:   make([]chan struct{}, config.Levels),
+		levels:  make([][]queued[T], config.Levels),
+		current: make([]int, config.Levels),
+	}
+	for i := range q.space {
+		q.space[i] = make(chan struct{}, config.Capacity)
+	}
+
+	go q.dispatch(ctx)
+	return q
+}
+
+// Jobs returns the channel to pass to a pool.
+func (q *PriorityQueue[T]) Jobs() <-chan pattern.Job[T] {
+	return q.out
+}
+
+// Submit queues a job with the given priority, blocking while that priority level is full.
+// It returns ErrQueueClosed once the queue is closed or its context is cancelled.
+func (q *PriorityQueue[T]) Submit(ctx context.Context, priority int, job pattern.Job[T]) error {
+	if priority < 0 || priority >= q.config.Levels {
+		return ErrInvalidPriority
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-q.done:
+		return ErrQueueClosed
+	case q.space[priority] <- struct{}{}:
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		<-q.space[priority]
+		return ErrQueueClosed
+	}
+	q.levels[priority] = append(q.levels[priority], queued[T]{job: job, enqueuedAt: time.Now()})
+	q.wake()
+	return nil
+}
+
+// Close stops accepting jobs, the jobs already queued are still handed to the pool.
+func (q *PriorityQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.wake()
+}
+
+// Len returns the number of jobs waiting in the queue. A pool fed by the queue reports a QueueDepth of zero,
+// as its jobs wait here rather than in the jobs channel.
+func (q *PriorityQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	total := 0
+	for _, level := range q.levels {
+		total += len(level)
+	}
+	return total
+}
+
+// wake notifies the dispatcher without blocking, must be called with the lock held.
+func (q *PriorityQueue[T]) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch hands queued jobs to the pool. It offers the best job according to the policy, and picks again
+// whenever the queue changes or a waiting job reaches MaxWait before a worker takes the offer.
+func (q *PriorityQueue[T]) dispatch(ctx context.Context) {
+	defer close(q.out)
+	defer q.shutdown()
+
+	for {
+		// Pick up any change before offering a job.
+		select {
+		case <-q.notify:
+		default:
+		}
+
+		level, aging, ok := q.pick()
+		if !ok {
+			if q.isClosed() {
+				return // queue closed and drained, exit dispatcher
+			}
+			select {
+			case <-ctx.Done():
+				return // context cancelled, exit dispatcher
+			case <-q.notify:
+			}
+			continue
+		}
+
+		var agingC <-chan time.Time
+		var timer *time.Timer
+		if aging > 0 {
+			timer = time.NewTimer(aging)
+			agingC = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return // context cancelled, exit dispatcher
+		case <-q.notify:
+		case <-agingC:
+		case q.out <- q.head(level):
+			q.pop(level)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// pick returns the level of the next job according to the policy, and how long until a queued job
+// reaches MaxWait, zero if none will.
+func (q *PriorityQueue[T]) pick() (level int, aging time.Duration, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Starved jobs go first, oldest first.
+	level = -1
+	if q.config.MaxWait > 0 {
+		now := time.Now()
+		var oldest time.Time
+		for i, jobs := range q.levels {
+			if len(jobs) == 0 {
+				continue
+			}
+			enqueuedAt := jobs[0].enqueuedAt
+			if wait := q.config.MaxWait - now.Sub(enqueuedAt); wait > 0 {
+				if aging == 0 || wait < aging {
+					aging = wait
+				}
+				continue
+			}
+			if level == -1 || enqueuedAt.Before(oldest) {
+				level, oldest = i, enqueuedAt
+			}
+		}
+		if level != -1 {
+			return level, 0, true
+		}
+	}
+
+	switch q.config.Policy {
+	case WeightedFair:
+		for i, jobs := range q.levels {
+			if len(jobs) > 0 && (level == -1 || q.current[i]+q.config.Weights[i] > q.current[level]+q.config.Weights[level]) {
+				level = i
+			}
+		}
+	default:
+		for i, jobs := range q.levels {
+			if len(jobs) > 0 {
+				level = i
+				break
+			}
+		}
+	}
+	return level, aging, level != -1
+}
+
+// head returns the job at the head of the given level.
+func (q *PriorityQueue[T]) head(level int) pattern.Job[T] {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.levels[level][0].job
+}
+
+// pop removes the job at the head of the given level once it was handed to the pool.
+func (q *PriorityQueue[T]) pop(level int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Smooth weighted round-robin: every waiting level earns its weight, the chosen one pays for the turn.
+	total := 0
+	for i, jobs := range q.levels {
+		if len(jobs) > 0 {
+			q.current[i] += q.config.Weights[i]
+			total += q.config.Weights[i]
+		}
+	}
+	q.current[level] -= total
+
+	q.levels[level][0] = queued[T]{}
+	q.levels[level] = q.levels[level][1:]
+	if len(q.levels[level]) == 0 {
+		q.current[level] = 0 // an empty level does not bank credit.
+	}
+	<-q.space[level]
+}
+
+// shutdown closes the queue once the dispatcher exits, so that Submit fails instead of queueing jobs
+// nobody will take, and drops the jobs left behind.
+func (q *PriorityQueue[T]) shutdown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	for i := range q.levels {
+		q.levels[i] = nil
+	}
+	close(q.done)
+}
+
+// isClosed reports whether the queue was closed.
+func (q *PriorityQueue[T]) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closed
+}
@@ -0,0 +1,155 @@
+package workerpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+// submitAll submits a job per ID with the given priority.
+func submitAll(t *testing.T, q *PriorityQueue[int], priority int, ids ...int) {
+	for _, id := range ids {
+		assert.NoError(t, q.Submit(context.Background(), priority, pattern.Job[int]{ID: id, Value: id}))
+	}
+}
+
+// takeIDs reads n jobs from the queue and returns their IDs.
+func takeIDs(q *PriorityQueue[int], n int) []int {
+	var ids []int
+	for i := 0; i < n; i++ {
+		ids = append(ids, (<-q.Jobs()).ID)
+	}
+	return ids
+}
+
+func TestPriorityQueue_Policies(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PriorityConfig
+		want   []int
+	}{
+		{
+			name:   "Strict priority",
+			config: PriorityConfig{Levels: 3, Policy: StrictPriority},
+			want:   []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+		{
+			name:   "Weighted fair, default weights",
+			config: PriorityConfig{Levels: 3, Policy: WeightedFair},
+			want:   []int{1, 4, 2, 7, 5, 3, 6, 8, 9},
+		},
+		{
+			name:   "Weighted fair, equal weights",
+			config: PriorityConfig{Levels: 3, Policy: WeightedFair, Weights: []int{1, 1, 1}},
+			want:   []int{1, 4, 7, 2, 5, 8, 3, 6, 9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			q := NewPriorityQueue[int](ctx, tt.config)
+			// Submit the lowest priority first, the queue must reorder the jobs.
+			submitAll(t, q, 2, 7, 8, 9)
+			submitAll(t, q, 1, 4, 5, 6)
+			submitAll(t, q, 0, 1, 2, 3)
+
+			assert.Equal(t, tt.want, takeIDs(q, len(tt.want)))
+		})
+	}
+}
+
+func TestPriorityQueue_StarvationProtection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	q := NewPriorityQueue[int](ctx, PriorityConfig{Levels: 2, MaxWait: 50 * time.Millisecond})
+	submitAll(t, q, 1, 100)
+	submitAll(t, q, 0, 1, 2)
+	assert.Equal(t, []int{1, 2}, takeIDs(q, 2))
+
+	// Once the low priority job waited for MaxWait it overtakes newer high priority jobs.
+	time.Sleep(60 * time.Millisecond)
+	submitAll(t, q, 0, 3, 4)
+	assert.Equal(t, []int{100, 3, 4}, takeIDs(q, 3))
+}
+
+func TestPriorityQueue_Submit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	q := NewPriorityQueue[int](ctx, PriorityConfig{Levels: 2, Capacity: 1})
+	assert.ErrorIs(t, q.Submit(ctx, 2, pattern.Job[int]{ID: 1}), ErrInvalidPriority)
+	assert.ErrorIs(t, q.Submit(ctx, -1, pattern.Job[int]{ID: 1}), ErrInvalidPriority)
+
+	// A full level blocks until the context is done.
+	assert.NoError(t, q.Submit(ctx, 1, pattern.Job[int]{ID: 1}))
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer timeoutCancel()
+	assert.ErrorIs(t, q.Submit(timeoutCtx, 1, pattern.Job[int]{ID: 2}), context.DeadlineExceeded)
+	assert.Equal(t, 1, q.Len())
+
+	// Closing the queue rejects new jobs but drains the queued ones.
+	q.Close()
+	assert.ErrorIs(t, q.Submit(ctx, 0, pattern.Job[int]{ID: 3}), ErrQueueClosed)
+
+	var ids []int
+	for job := range q.Jobs() {
+		ids = append(ids, job.ID)
+	}
+	assert.Equal(t, []int{1}, ids)
+}
+
+func TestPriorityQueue_SubmitAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := NewPriorityQueue[int](ctx, PriorityConfig{Levels: 2, Capacity: 1})
+	submitAll(t, q, 1, 1)
+
+	// The dispatcher exits without handing over the queued job.
+	cancel()
+	for range q.Jobs() {
+	}
+
+	assert.ErrorIs(t, q.Submit(context.Background(), 0, pattern.Job[int]{ID: 2}), ErrQueueClosed)
+	assert.ErrorIs(t, q.Submit(context.Background(), 1, pattern.Job[int]{ID: 3}), ErrQueueClosed, "a full level should not block")
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestPriorityQueue_WorkerPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	q := NewPriorityQueue[int](ctx, PriorityConfig{Levels: 2})
+	resultsChan := make(chan pattern.Result[int, int])
+
+	// Hold the single worker busy so that the following jobs queue up behind it.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blockFirst := func(ctx context.Context, value int) (int, error) {
+		if value == 0 {
+			close(started)
+			<-release
+		}
+		return squareNonNegative(ctx, value)
+	}
+	New(ctx, 1, q.Jobs(), resultsChan, blockFirst)
+
+	submitAll(t, q, 1, 0)
+	<-started
+	submitAll(t, q, 1, 1, 2, 3) // batch jobs
+	submitAll(t, q, 0, 4, 5)    // interactive jobs
+	q.Close()
+	close(release)
+
+	var ids []int
+	for result := range resultsChan {
+		ids = append(ids, result.Job.ID)
+	}
+	assert.Equal(t, []int{0, 4, 5, 1, 2, 3}, ids)
+}
@@ -0,0 +1,202 @@
+// Package workerpool implements the worker pool pattern.
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+// Pool is a worker pool whose number of workers can be changed while it is running.
+type Pool[T any, U any] struct {
+	ctx     context.Context
+	jobs    <-chan pattern.Job[T]
+	results chan<- pattern.Result[T, U]
+	process *pattern.Processor[T, U]
+
+	// sequencer and tickets are only set when results are ordered, see dispatch.
+	sequencer *pattern.Sequencer[T, U]
+	tickets   chan ticket[T]
+
+	mu      sync.Mutex
+	quits   []chan struct{} // quits holds one retirement signal per running worker.
+	stopped bool            // stopped is set once the pool no longer accepts new workers.
+
+	wg       sync.WaitGroup
+	busy     atomic.Int64
+	done     chan struct{} // done is closed once a worker observes the jobs channel is closed.
+	doneOnce sync.Once
+}
+
+// ticket holds a job numbered in the order it was received.
+type ticket[T any] struct {
+	seq int
+	job pattern.Job[T]
+}
+
+// New creates a pool of numWorkers workers reading from jobs and writing to results.
+// The options, such as pattern.WithRetry and pattern.WithDeadLetter, are applied around every call to process.
+// With pattern.WithOrderedResults, results are emitted in the order jobs were received.
+// The results channel is closed once the jobs channel is closed or the context is cancelled,
+// and all in-flight jobs have been processed.
+func New[T any, U any](ctx context.Context, numWorkers int, jobs <-chan pattern.Job[T], results chan<- pattern.Result[T, U], process pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) *Pool[T, U] {
+	p := &Pool[T, U]{
+		ctx:     ctx,
+		jobs:    jobs,
+		results: results,
+		process: pattern.NewProcessor(process, opts...),
+		done:    make(chan struct{}),
+	}
+	if p.sequencer = p.process.Sequencer(results); p.sequencer != nil {
+		p.tickets = make(chan ticket[T])
+		go p.dispatch()
+	}
+	p.Scale(numWorkers)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.done:
+		}
+
+		p.mu.Lock()
+		p.stopped = true
+		p.mu.Unlock()
+
+		p.wg.Wait()
+		close(results)
+	}()
+
+	return p
+}
+
+// CreateWorkerPool creates a pool of workers.
+func CreateWorkerPool[T any, U any](ctx context.Context, numWorkers int, jobs <-chan pattern.Job[T], results chan<- pattern.Result[T, U], process pattern.ProcessFunc[T, U], opts ...pattern.Option[T]) {
+	New(ctx, numWorkers, jobs, results, process, opts...)
+}
+
+// Scale grows or shrinks the pool to n workers, negative values are treated as zero.
+// Retired workers finish the job they are processing before exiting, so no in-flight job is dropped.
+// A pool scaled to zero workers does not notice the jobs channel closing until it is scaled up again.
+// Scale has no effect once the pool is shutting down.
+func (p *Pool[T, U]) Scale(n int) {
+	n = max(n, 0)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return
+	}
+
+	for len(p.quits) < n {
+		quit := make(chan struct{})
+		p.quits = append(p.quits, quit)
+		p.wg.Add(1)
+		go p.worker(quit)
+	}
+
+	for len(p.quits) > n {
+		last := len(p.quits) - 1
+		close(p.quits[last])
+		p.quits = p.quits[:last]
+	}
+}
+
+// Size returns the number of workers the pool is currently scaled to.
+func (p *Pool[T, U]) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.quits)
+}
+
+// Busy returns the number of workers currently processing a job.
+func (p *Pool[T, U]) Busy() int {
+	return int(p.busy.Load())
+}
+
+// QueueDepth returns the number of jobs buffered in the jobs channel waiting for a worker.
+// It is always zero when the pool is fed by a PriorityQueue, use PriorityQueue.Len instead.
+func (p *Pool[T, U]) QueueDepth() int {
+	return len(p.jobs)
+}
+
+// dispatch numbers jobs in the order they are received and hands them to the workers,
+// waiting for room in the reorder window before receiving each job.
+func (p *Pool[T, U]) dispatch() {
+	for seq := 0; ; seq++ {
+		if !p.sequencer.Acquire(p.ctx) {
+			return // context cancelled, exit dispatcher
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return // context cancelled, exit dispatcher
+		case job, ok := <-p.jobs:
+			if !ok {
+				close(p.tickets)
+				return // jobs channel closed, exit dispatcher
+			}
+			select {
+			case <-p.ctx.Done():
+				return
+			case p.tickets <- ticket[T]{seq: seq, job: job}:
+			}
+		}
+	}
+}
+
+// worker processes jobs and produces results until it is retired, the jobs channel is closed or the context is cancelled.
+func (p *Pool[T, U]) worker(quit <-chan struct{}) {
+	defer p.wg.Done()
+
+	// Ordered pools receive numbered jobs from the dispatcher instead of the jobs channel.
+	jobs := p.jobs
+	if p.sequencer != nil {
+		jobs = nil
+	}
+
+	for {
+		// Prefer retiring over picking up a new job.
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return // context cancelled, exit worker
+		case <-quit:
+			return // worker retired, exit worker
+		case job, ok := <-jobs:
+			if !ok {
+				p.doneOnce.Do(func() { close(p.done) })
+				return // jobs channel closed, exit worker
+			}
+			p.processJob(0, job)
+		case t, ok := <-p.tickets:
+			if !ok {
+				p.doneOnce.Do(func() { close(p.done) })
+				return // jobs channel closed, exit worker
+			}
+			p.processJob(t.seq, t.job)
+		}
+	}
+}
+
+// processJob processes a single job and emits its result, seq is only used when results are ordered.
+func (p *Pool[T, U]) processJob(seq int, job pattern.Job[T]) {
+	p.busy.Add(1)
+	defer p.busy.Add(-1)
+
+	result, ok := p.process.Process(p.ctx, job)
+	switch {
+	case p.sequencer != nil:
+		p.sequencer.Done(seq, result, ok)
+	case ok:
+		p.results <- result
+	}
+}
@@ -0,0 +1,277 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/gophercon2023/pkg/pattern"
+)
+
+var ErrNegativeValue = errors.New("negative value")
+
+// Example squareNonNegative function that squares non-negative integer.
+func squareNonNegative(ctx context.Context, value int) (int, error) {
+	slog.Info("squareNonNegative", "value", value, "ctx err", ctx.Err())
+	if value < 0 {
+		return 0, ErrNegativeValue
+	}
+	return value * value, nil
+}
+
+func TestWorkerPool(t *testing.T) {
+	type args[T any, U any] struct {
+		numWorkers int
+		jobs       []pattern.Job[T]
+		process    pattern.ProcessFunc[T, U]
+	}
+	type testCase[T any, U any] struct {
+		name   string
+		args   args[T, U]
+		cancel bool // whether to cancel the context before waiting for the result
+		want   []pattern.Result[T, U]
+	}
+
+	tests := []testCase[int, int]{
+		{
+			name: "Basic Test",
+			args: args[int, int]{
+				numWorkers: 2,
+				jobs: []pattern.Job[int]{
+					{ID: 1, Value: 2},
+					{ID: 2, Value: 3},
+					{ID: 3, Value: -1}, // negative value, should result in error
+				},
+				process: squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 2, Value: 3}, Value: 9, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 3, Value: -1}, Err: ErrNegativeValue, Attempts: 1},
+			},
+		},
+		{
+			name: "No Jobs",
+			args: args[int, int]{
+				numWorkers: 2,
+				jobs:       []pattern.Job[int]{}, // no jobs to squareNonNegative
+				process:    squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{},
+		},
+		{
+			name: "Single Worker",
+			args: args[int, int]{
+				numWorkers: 1,
+				jobs: []pattern.Job[int]{
+					{ID: 1, Value: 2},
+					{ID: 2, Value: 3},
+				},
+				process: squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 2, Value: 3}, Value: 9, Attempts: 1},
+			},
+		},
+		{
+			name: "Multiple Workers More Jobs",
+			args: args[int, int]{
+				numWorkers: 3,
+				jobs: []pattern.Job[int]{
+					{ID: 1, Value: 2},
+					{ID: 2, Value: 3},
+					{ID: 3, Value: 4},
+					{ID: 4, Value: 5},
+				},
+				process: squareNonNegative,
+			},
+			want: []pattern.Result[int, int]{
+				{Job: pattern.Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 2, Value: 3}, Value: 9, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 3, Value: 4}, Value: 16, Attempts: 1},
+				{Job: pattern.Job[int]{ID: 4, Value: 5}, Value: 25, Attempts: 1},
+			},
+		},
+		{
+			name: "Cancelled context",
+			args: args[int, int]{
+				numWorkers: 3,
+				jobs: []pattern.Job[int]{
+					{ID: 1, Value: 2},
+					{ID: 2, Value: 3},
+					{ID: 3, Value: 4},
+					{ID: 4, Value: 5},
+				},
+				process: squareNonNegative,
+			},
+			cancel: true,
+			want:   []pattern.Result[int, int]{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			jobsChan := make(chan pattern.Job[int], len(tt.args.jobs))
+			resultsChan := make(chan pattern.Result[int, int], len(tt.args.jobs))
+			CreateWorkerPool(ctx, tt.args.numWorkers, jobsChan, resultsChan, tt.args.process)
+			if tt.cancel {
+				cancel()                           // cancel the context before waiting for the result
+				time.Sleep(100 * time.Millisecond) // propagation of the context takes time
+			}
+
+			for _, job := range tt.args.jobs {
+				jobsChan <- job
+			}
+			close(jobsChan) // close jobs channel after feeding all jobs
+
+			var gotResults []pattern.Result[int, int]
+			for result := range resultsChan {
+				gotResults = append(gotResults, result)
+			}
+
+			assert.ElementsMatch(t, tt.want, gotResults)
+		})
+	}
+}
+
+func TestPool_Scale(t *testing.T) {
+	tests := []struct {
+		name  string
+		start int
+		scale []int
+		want  int
+	}{
+		{name: "Grow", start: 1, scale: []int{4}, want: 4},
+		{name: "Shrink", start: 4, scale: []int{1}, want: 1},
+		{name: "Shrink to zero", start: 3, scale: []int{0}, want: 0},
+		{name: "Negative size", start: 2, scale: []int{-1}, want: 0},
+		{name: "Grow and shrink", start: 2, scale: []int{5, 3, 6, 2}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			jobsChan := make(chan pattern.Job[int])
+			resultsChan := make(chan pattern.Result[int, int])
+			pool := New(ctx, tt.start, jobsChan, resultsChan, squareNonNegative)
+			assert.Equal(t, max(tt.start, 0), pool.Size())
+
+			for _, n := range tt.scale {
+				pool.Scale(n)
+			}
+			assert.Equal(t, tt.want, pool.Size())
+
+			close(jobsChan)
+			cancel()
+			for range resultsChan {
+			}
+		})
+	}
+}
+
+func TestPool_ScaleDownKeepsInFlightJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	numJobs := 4
+	release := make(chan struct{})
+	started := make(chan struct{}, numJobs)
+	blocking := func(ctx context.Context, value int) (int, error) {
+		started <- struct{}{}
+		<-release
+		return squareNonNegative(ctx, value)
+	}
+
+	jobsChan := make(chan pattern.Job[int], numJobs)
+	resultsChan := make(chan pattern.Result[int, int], numJobs)
+	pool := New(ctx, numJobs, jobsChan, resultsChan, blocking)
+
+	var want []pattern.Result[int, int]
+	for i := 1; i <= numJobs; i++ {
+		jobsChan <- pattern.Job[int]{ID: i, Value: i}
+		want = append(want, pattern.Result[int, int]{Job: pattern.Job[int]{ID: i, Value: i}, Value: i * i, Attempts: 1})
+	}
+	for i := 0; i < numJobs; i++ {
+		<-started
+	}
+	assert.Equal(t, numJobs, pool.Busy())
+
+	// Retire all but one worker while every worker is busy.
+	pool.Scale(1)
+	assert.Equal(t, 1, pool.Size())
+	close(release)
+	close(jobsChan)
+
+	var gotResults []pattern.Result[int, int]
+	for result := range resultsChan {
+		gotResults = append(gotResults, result)
+	}
+	assert.ElementsMatch(t, want, gotResults)
+	assert.Equal(t, 0, pool.Busy())
+}
+
+func TestPool_QueueDepth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobsChan := make(chan pattern.Job[int], 10)
+	resultsChan := make(chan pattern.Result[int, int], 10)
+	pool := New(ctx, 0, jobsChan, resultsChan, squareNonNegative)
+
+	for i := 1; i <= 5; i++ {
+		jobsChan <- pattern.Job[int]{ID: i, Value: i}
+	}
+	assert.Equal(t, 5, pool.QueueDepth())
+
+	// Scaling up drains the backlog.
+	pool.Scale(2)
+	for i := 0; i < 5; i++ {
+		<-resultsChan
+	}
+	assert.Equal(t, 0, pool.QueueDepth())
+
+	close(jobsChan)
+	for range resultsChan {
+	}
+}
+
+func TestPool_OrderedResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	numJobs := 50
+	jobsChan := make(chan pattern.Job[int], numJobs)
+	resultsChan := make(chan pattern.Result[int, int])
+
+	// Odd jobs are slow, so later even jobs finish first.
+	unevenFunc := func(ctx context.Context, value int) (int, error) {
+		if value%2 == 1 {
+			time.Sleep(time.Millisecond)
+		}
+		return squareNonNegative(ctx, value)
+	}
+	New(ctx, 4, jobsChan, resultsChan, unevenFunc, pattern.WithOrderedResults[int](4))
+
+	var want []pattern.Result[int, int]
+	for i := 1; i <= numJobs; i++ {
+		jobsChan <- pattern.Job[int]{ID: i, Value: i}
+		want = append(want, pattern.Result[int, int]{Job: pattern.Job[int]{ID: i, Value: i}, Value: i * i, Attempts: 1})
+	}
+	close(jobsChan)
+
+	var gotResults []pattern.Result[int, int]
+	for result := range resultsChan {
+		gotResults = append(gotResults, result)
+	}
+	assert.Equal(t, want, gotResults)
+}